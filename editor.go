@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/history"
+)
+
+// Reading lines at the prompt. On a terminal we use a line editor (cursor
+// movement, up/down through history, tab completion). Anything else, e.g
+// piped input, is read a line at a time with a plain prompt.
+
+const prompt = "Pokedex > "
+
+// Returned by ReadLine when Ctrl-C is pressed at the prompt. The line
+// editor reads the terminal in raw mode so Ctrl-C arrives as a key rather
+// than SIGINT.
+var errPromptInterrupted = errors.New("interrupted at the prompt")
+
+type lineReader interface {
+	// Prompt for and read the next line. io.EOF when input ends.
+	ReadLine() (string, error)
+	// Put the terminal back how we found it. Safe to call while ReadLine
+	// is blocked, e.g when shutting down on SIGTERM.
+	Close() error
+}
+
+// Gives the candidates for the word being typed at the end of line
+type completer func(line string) []string
+
+// A line editor if stdin and stdout are a terminal, otherwise a plain reader.
+// hist (which may be nil) and complete are only used by the line editor.
+func newLineReader(hist *history.History, complete completer) lineReader {
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if term.IsTerminal(stdin) && term.IsTerminal(stdout) {
+		return newEditor(stdin, hist, complete)
+	}
+	return &plainReader{scanner: bufio.NewScanner(os.Stdin)}
+}
+
+type plainReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *plainReader) ReadLine() (string, error) {
+	//Notice lack of newline
+	fmt.Print(prompt)
+	if !r.scanner.Scan() {
+		// Err is nil on a plain EOF
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+func (r *plainReader) Close() error {
+	return nil
+}
+
+type editor struct {
+	fd       int
+	terminal *term.Terminal
+	input    *ctrlCReader
+
+	// Terminal state to restore, set while in raw mode
+	mu       sync.Mutex
+	oldState *term.State
+}
+
+func newEditor(fd int, hist *history.History, complete completer) *editor {
+	e := &editor{fd: fd, input: &ctrlCReader{r: os.Stdin}}
+	e.terminal = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{e.input, os.Stdout}, prompt)
+	if hist != nil {
+		e.terminal.History = editorHistory{hist}
+	}
+	e.terminal.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		newLine, newPos, matches := completeLine(line, pos, complete)
+		// Nothing more we can fill in - show the options instead
+		if len(matches) > 1 && newLine == line {
+			fmt.Fprintln(e.terminal, strings.Join(matches, "  "))
+		}
+		return newLine, newPos, true
+	}
+	return e
+}
+
+// The editor only reads history for the up/down arrows. The REPL adds each
+// line itself, once any !-reference in it has been expanded.
+type editorHistory struct {
+	*history.History
+}
+
+func (editorHistory) Add(string) {}
+
+// Raw mode only while reading a line, so commands print and handle Ctrl-C
+// as normal.
+func (e *editor) ReadLine() (string, error) {
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", fmt.Errorf("could not set up the terminal: %v", err)
+	}
+	e.mu.Lock()
+	e.oldState = state
+	e.mu.Unlock()
+	defer e.Close()
+
+	// Keep line wrapping right if the window was resized
+	if width, height, err := term.GetSize(e.fd); err == nil {
+		e.terminal.SetSize(width, height)
+	}
+
+	e.input.reset()
+	line, err := e.terminal.ReadLine()
+	if err == nil && e.input.sawCtrlC() {
+		return "", errPromptInterrupted
+	}
+	if errors.Is(err, term.ErrPasteIndicator) {
+		err = nil
+	}
+	return line, err
+}
+
+func (e *editor) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.oldState == nil {
+		return nil
+	}
+	err := term.Restore(e.fd, e.oldState)
+	e.oldState = nil
+	return err
+}
+
+// The line editor treats Ctrl-C like Ctrl-D and returns io.EOF, leaving the
+// half typed line behind for next time. Instead we swap Ctrl-C for Ctrl-E,
+// Ctrl-U, Enter - i.e clear the line and submit it - and note that it went
+// past so ReadLine can report it.
+var ctrlCKeys = []byte{5, 21, '\r'}
+
+type ctrlCReader struct {
+	r io.Reader
+	// Bytes read but not yet handed on, as swapping keys makes more of them
+	pending []byte
+
+	mu    sync.Mutex
+	ctrlC bool
+}
+
+func (c *ctrlCReader) Read(p []byte) (int, error) {
+	if len(c.pending) == 0 {
+		buf := make([]byte, len(p))
+		n, err := c.r.Read(buf)
+		if n == 0 {
+			return 0, err
+		}
+		for _, b := range buf[:n] {
+			if b != 3 {
+				c.pending = append(c.pending, b)
+				continue
+			}
+			c.pending = append(c.pending, ctrlCKeys...)
+			c.mu.Lock()
+			c.ctrlC = true
+			c.mu.Unlock()
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *ctrlCReader) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctrlC = false
+}
+
+func (c *ctrlCReader) sawCtrlC() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ctrlC
+}
+
+// Complete the word before pos in line. With one match the word is finished
+// off (plus a space), with several it is extended as far as they agree.
+// Returns the new line and cursor position along with every match.
+func completeLine(line string, pos int, complete completer) (string, int, []string) {
+	before, after := line[:pos], line[pos:]
+	matches := complete(before)
+	if len(matches) == 0 {
+		return line, pos, nil
+	}
+
+	start := strings.LastIndexAny(before, " \t") + 1
+	word := before[start:]
+
+	completed := matches[0] + " "
+	if len(matches) > 1 {
+		completed = commonPrefix(matches)
+	}
+	if len(completed) < len(word) {
+		return line, pos, matches
+	}
+	newBefore := before[:start] + completed
+	return newBefore + after, len(newBefore), matches
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
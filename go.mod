@@ -1,3 +1,10 @@
 module github.com/Fraegdegjevar/pokedexcli
 
 go 1.25.1
+
+require (
+	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/term v0.45.0
+)
+
+require golang.org/x/sys v0.47.0 // indirect
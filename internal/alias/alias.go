@@ -0,0 +1,142 @@
+package alias
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/atomicfile"
+)
+
+// User-defined aliases and macros. Each maps a name to one or more commands
+// separated by ';', e.g
+//
+//	{
+//	  "cp": "catch pikachu",
+//	  "tour": "map; explore pastoria-city-area"
+//	}
+//
+// The file lives next to the settings file and can be edited by hand or with
+// the alias command. Expanding them is up to the command package - see
+// ExecuteCommand.
+
+// Separates the commands in a macro
+const Separator = ";"
+
+// Alias names are typed at the prompt so keep them to one simple word
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+type Store struct {
+	Path   string
+	Macros map[string]string
+}
+
+// Path of the aliases file, next to the settings file at settingsPath
+func DefaultPath(settingsPath string) string {
+	return filepath.Join(filepath.Dir(settingsPath), "aliases.json")
+}
+
+// Read aliases from path. A missing file is not an error, there just aren't
+// any aliases yet.
+func Load(path string) (*Store, error) {
+	s := &Store{Path: path, Macros: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read aliases file %v: %v", path, err)
+	}
+
+	err = json.Unmarshal(data, &s.Macros)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse aliases file %v: %v", path, err)
+	}
+	if s.Macros == nil {
+		s.Macros = make(map[string]string)
+	}
+
+	// The file can be edited by hand, so hold it to the same rules as Set
+	for name, expansion := range s.Macros {
+		if err := validate(name, expansion); err != nil {
+			return nil, fmt.Errorf("bad alias in %v: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+// Look up an alias. Safe to call on a nil Store, which has no aliases.
+func (s *Store) Get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	expansion, ok := s.Macros[name]
+	return expansion, ok
+}
+
+// Alias names in order
+func (s *Store) Names() []string {
+	if s == nil {
+		return nil
+	}
+	names := make([]string, 0, len(s.Macros))
+	for name := range s.Macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Add or replace an alias and save the file.
+func (s *Store) Set(name, expansion string) error {
+	if err := validate(name, expansion); err != nil {
+		return err
+	}
+	s.Macros[name] = strings.TrimSpace(expansion)
+	return s.save()
+}
+
+func validate(name, expansion string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid alias name %q: use lower case letters, digits, - and _", name)
+	}
+	if len(Commands(expansion)) == 0 {
+		return fmt.Errorf("alias %v has no commands", name)
+	}
+	return nil
+}
+
+// Remove an alias and save the file.
+func (s *Store) Delete(name string) error {
+	if _, ok := s.Macros[name]; !ok {
+		return fmt.Errorf("no alias called %v", name)
+	}
+	delete(s.Macros, name)
+	return s.save()
+}
+
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.Macros, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding aliases: %v", err)
+	}
+	return atomicfile.WriteFile(s.Path, data, 0o644)
+}
+
+// Split an expansion into its commands, each split into words. Empty
+// commands (e.g a trailing ;) are dropped.
+func Commands(expansion string) [][]string {
+	var commands [][]string
+	for _, part := range strings.Split(expansion, Separator) {
+		words := strings.Fields(strings.ToLower(part))
+		if len(words) > 0 {
+			commands = append(commands, words)
+		}
+	}
+	return commands
+}
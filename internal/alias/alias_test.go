@@ -0,0 +1,87 @@
+package alias
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+
+	// Missing file is just no aliases
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading missing file: %v", err)
+	}
+	if len(s.Names()) != 0 {
+		t.Fatalf("Expected no aliases, got: %v", s.Names())
+	}
+
+	if err := s.Set("tour", "map; explore pastoria-city-area"); err != nil {
+		t.Fatalf("Unexpected error setting alias: %v", err)
+	}
+	if err := s.Set("cp", "catch pikachu"); err != nil {
+		t.Fatalf("Unexpected error setting alias: %v", err)
+	}
+	if err := s.Set("Bad Name", "map"); err == nil {
+		t.Errorf("Expected an error for an invalid name")
+	}
+	if err := s.Set("empty", " ; "); err == nil {
+		t.Errorf("Expected an error for an alias with no commands")
+	}
+
+	// Saved aliases load back
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading aliases: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Names(), []string{"cp", "tour"}) {
+		t.Errorf("Expected aliases [cp tour], got: %v", loaded.Names())
+	}
+
+	if err := loaded.Delete("cp"); err != nil {
+		t.Fatalf("Unexpected error deleting alias: %v", err)
+	}
+	if err := loaded.Delete("cp"); err == nil {
+		t.Errorf("Expected an error deleting a missing alias")
+	}
+	if _, ok := loaded.Get("cp"); ok {
+		t.Errorf("Expected cp to be deleted")
+	}
+
+	// Corrupt files are an error so we don't save over them
+	os.WriteFile(path, []byte("{not json"), 0o644)
+	if _, err := Load(path); err == nil {
+		t.Errorf("Expected an error loading a corrupt file")
+	}
+
+	// So are hand edits Set wouldn't allow
+	for _, data := range []string{`{"x": ";"}`, `{"x": ""}`, `{"Bad Name": "map"}`} {
+		os.WriteFile(path, []byte(data), 0o644)
+		if _, err := Load(path); err == nil {
+			t.Errorf("Expected an error loading %v", data)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected [][]string
+	}{
+		{input: "catch pikachu", expected: [][]string{{"catch", "pikachu"}}},
+		{input: "map;  Explore Pastoria ; ", expected: [][]string{{"map"}, {"explore", "pastoria"}}},
+		{input: " ; ", expected: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			got := Commands(c.input)
+			if !reflect.DeepEqual(got, c.expected) {
+				t.Errorf("Expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to path atomically. We write to a temp file in the
+// same directory then rename over the real one, as rename is atomic - so a
+// crash mid-write leaves at worst a stray temp file (prefixed "tmp-") and
+// never a half-written file. Parent directories are created if needed.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create directory %v: %v", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %v", err)
+	}
+	// Clean up the temp file if anything below fails. After a successful
+	// rename this is a no-op.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing %v: %v", path, err)
+	}
+	// Make sure the data is on disk before the rename makes it visible
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing %v: %v", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error setting permissions on %v: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing %v: %v", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error moving %v into place: %v", path, err)
+	}
+	return nil
+}
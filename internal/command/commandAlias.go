@@ -0,0 +1,75 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// alias [list] | set <name> <command>[; <command>...] | delete <name>
+func commandAlias(ctx context.Context, env *Env, args []string) error {
+	supportedCommands := GetSupportedCommands()
+
+	// No subcommand - list built-in and user aliases
+	if len(args) == 0 || args[0] == "list" {
+		names := make([]string, 0, len(supportedCommands))
+		for name := range supportedCommands {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		fmt.Fprintln(env.Stdout, "Built-in aliases:")
+		for _, name := range names {
+			for _, a := range supportedCommands[name].Aliases {
+				fmt.Fprintf(env.Stdout, "  %s = %s\n", a, name)
+			}
+		}
+
+		fmt.Fprintln(env.Stdout, "Your aliases:")
+		userAliases := env.Aliases.Names()
+		if len(userAliases) == 0 {
+			fmt.Fprintln(env.Stdout, "  none yet - see 'help alias'")
+		}
+		for _, name := range userAliases {
+			expansion, _ := env.Aliases.Get(name)
+			fmt.Fprintf(env.Stdout, "  %s = %s\n", name, expansion)
+		}
+		return nil
+	}
+
+	if env.Aliases == nil {
+		return fmt.Errorf("aliases are not available")
+	}
+
+	switch args[0] {
+	case "set":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: alias set <name> <command>[; <command>...]")
+		}
+		name := args[1]
+		// Commands and built-in aliases always win, so an alias with the
+		// same name could never run
+		if _, exists := lookupCommand(supportedCommands, name); exists {
+			return fmt.Errorf("%v is already a command", name)
+		}
+		expansion := strings.Join(args[2:], " ")
+		err := env.Aliases.Set(name, expansion)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stdout, "Alias %v = %v\n", name, expansion)
+	case "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: alias delete <name>")
+		}
+		err := env.Aliases.Delete(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stdout, "Deleted alias %v.\n", args[1])
+	default:
+		return fmt.Errorf("unknown alias subcommand %v: use list, set or delete", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package command
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+// How many catches coverage suggests at most
+const maxSuggestions = 5
+
+// What coverage shows for a team of caught pokemon
+type coverageResult struct {
+	Team             []matchupSide    `json:"team"`
+	SharedWeaknesses []sharedWeakness `json:"shared_weaknesses"`
+	// Defending types none of the team's own types hit super effectively
+	Uncovered   []string          `json:"uncovered"`
+	Suggestions []catchSuggestion `json:"suggestions"`
+}
+
+// An attacking type more of the team is weak to than resists
+type sharedWeakness struct {
+	Type     string   `json:"type"`
+	Weak     []string `json:"weak"`
+	Resisted []string `json:"resisted_by"`
+}
+
+// A pokemon from an explored area that would fill a gap in the team
+type catchSuggestion struct {
+	Pokemon string   `json:"pokemon"`
+	Types   []string `json:"types"`
+	Areas   []string `json:"areas"`
+	// Uncovered types it hits super effectively
+	Covers []string `json:"covers"`
+	// Shared weaknesses it resists
+	Resists []string `json:"resists"`
+}
+
+func (r coverageResult) WriteText(w io.Writer) error {
+	team := make([]string, 0, len(r.Team))
+	for _, member := range r.Team {
+		team = append(team, member.String())
+	}
+	fmt.Fprintf(w, "Team: %v\n", strings.Join(team, ", "))
+
+	fmt.Fprintln(w, "\nShared weaknesses:")
+	if len(r.SharedWeaknesses) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for _, sw := range r.SharedWeaknesses {
+		fmt.Fprintf(w, "  %v: %v weak", sw.Type, strings.Join(sw.Weak, ", "))
+		if len(sw.Resisted) > 0 {
+			fmt.Fprintf(w, ", resisted by %v", strings.Join(sw.Resisted, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "\nTypes nothing hits super effectively: %v\n", joinOr(r.Uncovered, "none"))
+
+	fmt.Fprintln(w, "\nSuggested catches from explored areas:")
+	if len(r.Suggestions) == 0 {
+		fmt.Fprintln(w, "  none - explore some more areas")
+	}
+	for _, s := range r.Suggestions {
+		fmt.Fprintf(w, "  %v in %v\n", matchupSide{Name: s.Pokemon, Types: s.Types}, strings.Join(s.Areas, ", "))
+		if len(s.Covers) > 0 {
+			fmt.Fprintf(w, "    hits %v\n", strings.Join(s.Covers, ", "))
+		}
+		if len(s.Resists) > 0 {
+			fmt.Fprintf(w, "    resists %v\n", strings.Join(s.Resists, ", "))
+		}
+	}
+	return nil
+}
+
+func joinOr(list []string, fallback string) string {
+	if len(list) == 0 {
+		return fallback
+	}
+	return strings.Join(list, ", ")
+}
+
+// One row per finding, e.g ("weakness", "ground", "weak: pikachu, raichu")
+func (r coverageResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	for _, sw := range r.SharedWeaknesses {
+		rows = append(rows, []string{"weakness", sw.Type,
+			fmt.Sprintf("weak: %v; resisted by: %v", strings.Join(sw.Weak, ","), joinOr(sw.Resisted, "none"))})
+	}
+	for _, t := range r.Uncovered {
+		rows = append(rows, []string{"uncovered", t, ""})
+	}
+	for _, s := range r.Suggestions {
+		rows = append(rows, []string{"suggestion", s.Pokemon,
+			fmt.Sprintf("hits: %v; resists: %v; in: %v", joinOr(s.Covers, "none"), joinOr(s.Resists, "none"), strings.Join(s.Areas, ","))})
+	}
+	return []string{"kind", "name", "detail"}, rows
+}
+
+// coverage [pokemon...] looks at the whole Pokedex, or just the pokemon given,
+// as a team. Attacking coverage assumes each pokemon uses moves of its own
+// types, which is close enough for picking what to catch next.
+func commandCoverage(ctx context.Context, env *Env, args []string) error {
+	names := slices.Clone(args)
+	if len(names) == 0 {
+		for name := range env.Config.Pokedex {
+			names = append(names, name)
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("your Pokedex is empty - catch some pokemon first")
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	// Every battle type is needed to work out what the team doesn't cover,
+	// so fetch them all up front (they're cached after the first time)
+	all, err := env.Config.GetTypesContext(ctx, pokeapi.BattleTypes)
+	if err != nil {
+		return err
+	}
+	typesByName := make(map[string]pokeapi.Type, len(all))
+	for _, t := range all {
+		typesByName[t.Name] = t
+	}
+
+	result := coverageResult{
+		Team:             []matchupSide{},
+		SharedWeaknesses: []sharedWeakness{},
+		Uncovered:        []string{},
+		Suggestions:      []catchSuggestion{},
+	}
+	teamTypes := make(map[string][]pokeapi.Type)
+	for _, name := range names {
+		pokemon, caught := env.Config.Pokedex[name]
+		if !caught {
+			return fmt.Errorf("you have not caught %v", name)
+		}
+		side := matchupSide{Name: name, Types: pokemon.TypeNames()}
+		result.Team = append(result.Team, side)
+		teamTypes[name] = lookupTypes(typesByName, side.Types)
+	}
+
+	// A type is a shared weakness if more of the team is weak to it than
+	// resists it, and at least two are weak (or the only one, for a team of one)
+	threshold := min(2, len(names))
+	for _, attacking := range pokeapi.BattleTypes {
+		sw := sharedWeakness{Type: attacking, Weak: []string{}, Resisted: []string{}}
+		for _, name := range names {
+			switch m := pokeapi.Effectiveness(attacking, teamTypes[name]); {
+			case m > 1:
+				sw.Weak = append(sw.Weak, name)
+			case m < 1:
+				sw.Resisted = append(sw.Resisted, name)
+			}
+		}
+		if len(sw.Weak) >= threshold && len(sw.Weak) > len(sw.Resisted) {
+			result.SharedWeaknesses = append(result.SharedWeaknesses, sw)
+		}
+	}
+	slices.SortStableFunc(result.SharedWeaknesses, func(a, b sharedWeakness) int {
+		return cmp.Compare(len(b.Weak), len(a.Weak))
+	})
+
+	var attackingTypes []string
+	for _, member := range result.Team {
+		attackingTypes = append(attackingTypes, member.Types...)
+	}
+	for _, defending := range pokeapi.BattleTypes {
+		if !hitsSuperEffectively(attackingTypes, typesByName[defending]) {
+			result.Uncovered = append(result.Uncovered, defending)
+		}
+	}
+
+	result.Suggestions = suggestCatches(ctx, env, typesByName, result)
+	return render(env, result)
+}
+
+// Pokemon found in explored areas that hit an uncovered type super effectively
+// or resist a shared weakness, best first. Explored areas are saved with the
+// Pokedex, so this covers earlier sessions too. Pokemon we can't get are skipped.
+func suggestCatches(ctx context.Context, env *Env, typesByName map[string]pokeapi.Type, result coverageResult) []catchSuggestion {
+	areasByPokemon := make(map[string][]string)
+	for area, found := range env.Config.ExploredAreas() {
+		for _, name := range found {
+			if _, caught := env.Config.Pokedex[name]; !caught && !slices.Contains(areasByPokemon[name], area) {
+				areasByPokemon[name] = append(areasByPokemon[name], area)
+			}
+		}
+	}
+
+	suggestions := []catchSuggestion{}
+	for name, areas := range areasByPokemon {
+		if ctx.Err() != nil {
+			break
+		}
+		pokemon, err := env.Config.GetPokemonContext(ctx, name)
+		if err != nil {
+			fmt.Fprintf(env.Stderr, "could not get %v for suggestions: %v\n", name, err)
+			continue
+		}
+		slices.Sort(areas)
+		s := catchSuggestion{Pokemon: name, Types: pokemon.TypeNames(), Areas: areas, Covers: []string{}, Resists: []string{}}
+		for _, uncovered := range result.Uncovered {
+			if hitsSuperEffectively(s.Types, typesByName[uncovered]) {
+				s.Covers = append(s.Covers, uncovered)
+			}
+		}
+		defending := lookupTypes(typesByName, s.Types)
+		for _, sw := range result.SharedWeaknesses {
+			if pokeapi.Effectiveness(sw.Type, defending) < 1 {
+				s.Resists = append(s.Resists, sw.Type)
+			}
+		}
+		if len(s.Covers)+len(s.Resists) > 0 {
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	// Most gaps filled first, then by name as map order is random
+	slices.SortFunc(suggestions, func(a, b catchSuggestion) int {
+		return cmp.Or(
+			cmp.Compare(len(b.Covers)+len(b.Resists), len(a.Covers)+len(a.Resists)),
+			cmp.Compare(a.Pokemon, b.Pokemon),
+		)
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// Whether a move of any of the attacking types is super effective against defending
+func hitsSuperEffectively(attacking []string, defending pokeapi.Type) bool {
+	return slices.ContainsFunc(attacking, func(t string) bool {
+		return defending.DamageFrom(t) > 1
+	})
+}
+
+// Types not in the map (e.g newer types than BattleTypes) are left out
+func lookupTypes(typesByName map[string]pokeapi.Type, names []string) []pokeapi.Type {
+	var types []pokeapi.Type
+	for _, name := range names {
+		if t, ok := typesByName[name]; ok {
+			types = append(types, t)
+		}
+	}
+	return types
+}
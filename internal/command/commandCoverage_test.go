@@ -0,0 +1,124 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+func TestCommandCoverage(t *testing.T) {
+	t.Parallel()
+	stubs := map[string]string{
+		// Only the defending side of each type, which is all coverage uses
+		"/api/v2/type/electric": `{
+			"name": "electric",
+			"damage_relations": {
+				"half_damage_from": [{"name": "electric"}, {"name": "flying"}, {"name": "steel"}],
+				"double_damage_from": [{"name": "ground"}]
+			}
+		}`,
+		"/api/v2/type/water": `{
+			"name": "water",
+			"damage_relations": {
+				"half_damage_from": [{"name": "fire"}, {"name": "water"}, {"name": "ice"}, {"name": "steel"}],
+				"double_damage_from": [{"name": "electric"}, {"name": "grass"}]
+			}
+		}`,
+		"/api/v2/type/flying": `{
+			"name": "flying",
+			"damage_relations": {
+				"no_damage_from": [{"name": "ground"}],
+				"half_damage_from": [{"name": "grass"}, {"name": "fighting"}, {"name": "bug"}],
+				"double_damage_from": [{"name": "electric"}, {"name": "ice"}, {"name": "rock"}]
+			}
+		}`,
+		"/api/v2/pokemon/gyarados": `{
+			"name": "gyarados",
+			"types": [{"slot": 2, "type": {"name": "flying"}}, {"slot": 1, "type": {"name": "water"}}]
+		}`,
+		// magikarp isn't stubbed, so can't be fetched
+		"/api/v2/location-area/lake-verity-front": `{
+			"name": "lake-verity-front",
+			"pokemon_encounters": [{"pokemon": {"name": "gyarados"}}, {"pokemon": {"name": "magikarp"}}, {"pokemon": {"name": "pikachu"}}]
+		}`,
+	}
+	electric := []pokeapi.PokemonType{{Slot: 1, Type: pokeapi.NamedAPIResource{Name: "electric"}}}
+	pokedex := map[string]pokeapi.Pokemon{
+		"pikachu": {Name: "pikachu", Types: electric},
+		"jolteon": {Name: "jolteon", Types: electric},
+	}
+	cases := []struct {
+		name     string
+		pokedex  map[string]pokeapi.Pokemon
+		explore  bool
+		args     []string
+		expected []string
+		stderr   string
+		wantErr  bool
+	}{
+		{
+			name:    "whole pokedex",
+			pokedex: pokedex,
+			expected: []string{
+				"Team: jolteon (electric), pikachu (electric)\n",
+				"Shared weaknesses:\n  ground: jolteon, pikachu weak\n",
+				// The stubs only make electric super effective against water and flying
+				"Types nothing hits super effectively: normal, fire, electric, grass,",
+				"Suggested catches from explored areas:\n  none - explore some more areas\n",
+			},
+		},
+		{
+			// gyarados is immune to ground, pikachu is caught and magikarp can't be fetched
+			name:    "suggestions from explored areas",
+			pokedex: pokedex,
+			explore: true,
+			expected: []string{
+				"Suggested catches from explored areas:\n  gyarados (water, flying) in lake-verity-front\n    resists ground\n",
+			},
+			stderr: "could not get magikarp for suggestions",
+		},
+		{
+			// A team of one's weaknesses are all shared
+			name:     "subset",
+			pokedex:  map[string]pokeapi.Pokemon{"gyarados": {Name: "gyarados", Types: []pokeapi.PokemonType{{Slot: 1, Type: pokeapi.NamedAPIResource{Name: "water"}}, {Slot: 2, Type: pokeapi.NamedAPIResource{Name: "flying"}}}}, "pikachu": pokedex["pikachu"]},
+			args:     []string{"gyarados"},
+			expected: []string{"Team: gyarados (water, flying)\n", "  electric: gyarados weak\n  rock: gyarados weak\n"},
+		},
+		{
+			name:    "not caught",
+			pokedex: pokedex,
+			args:    []string{"gyarados"},
+			wantErr: true,
+		},
+		{
+			name:    "empty pokedex",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, stderr := newTestEnv(t, &pokeapi.Config{Client: stubAPIClient(stubs), Pokedex: c.pokedex})
+			if c.explore {
+				if _, err := env.Config.GetLocationArea("lake-verity-front"); err != nil {
+					t.Fatalf("Error exploring: %v", err)
+				}
+			}
+			err := commandCoverage(context.Background(), env, c.args)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Expected error %v, got: %v", c.wantErr, err)
+			}
+			for _, want := range c.expected {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%v", want, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), c.stderr) {
+				t.Errorf("Expected stderr to contain %q, got %q", c.stderr, stderr.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+// A pokemon's evolution chain as a tree, with the stages already in the
+// Pokedex marked as caught
+type evolutionsResult struct {
+	Chain evolutionStage `json:"chain"`
+}
+
+type evolutionStage struct {
+	Species string `json:"species"`
+	// How the previous stage evolves into this one
+	Conditions string           `json:"conditions,omitempty"`
+	Baby       bool             `json:"baby,omitempty"`
+	Caught     bool             `json:"caught"`
+	EvolvesTo  []evolutionStage `json:"evolves_to"`
+}
+
+func newEvolutionStage(link pokeapi.ChainLink, caught map[string]bool) evolutionStage {
+	stage := evolutionStage{
+		Species:    link.Species.Name,
+		Conditions: link.Conditions(),
+		Baby:       link.Is_Baby,
+		Caught:     caught[link.Species.Name],
+		EvolvesTo:  []evolutionStage{},
+	}
+	for _, next := range link.Evolves_To {
+		stage.EvolvesTo = append(stage.EvolvesTo, newEvolutionStage(next, caught))
+	}
+	return stage
+}
+
+// e.g "ivysaur (level 16) [caught]"
+func (s evolutionStage) label() string {
+	label := s.Species
+	if s.Conditions != "" {
+		label += " (" + s.Conditions + ")"
+	}
+	if s.Baby {
+		label += " [baby]"
+	}
+	if s.Caught {
+		label += " [caught]"
+	}
+	return label
+}
+
+// Draw the chain as an ASCII tree:
+//
+//	eevee [caught]
+//	|-- vaporeon (use water-stone)
+//	|-- jolteon (use thunder-stone) [caught]
+//	`-- flareon (use fire-stone)
+func (r evolutionsResult) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, r.Chain.label())
+	writeEvolutionBranches(w, r.Chain.EvolvesTo, "")
+	return nil
+}
+
+func writeEvolutionBranches(w io.Writer, stages []evolutionStage, indent string) {
+	for i, stage := range stages {
+		branch, childIndent := "|-- ", "|   "
+		if i == len(stages)-1 {
+			branch, childIndent = "`-- ", "    "
+		}
+		fmt.Fprintln(w, indent+branch+stage.label())
+		writeEvolutionBranches(w, stage.EvolvesTo, indent+childIndent)
+	}
+}
+
+// One row per stage, in tree order
+func (r evolutionsResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	var addRows func(s evolutionStage, from string, depth int)
+	addRows = func(s evolutionStage, from string, depth int) {
+		rows = append(rows, []string{strconv.Itoa(depth + 1), s.Species, from, s.Conditions, strconv.FormatBool(s.Caught)})
+		for _, next := range s.EvolvesTo {
+			addRows(next, s.Species, depth+1)
+		}
+	}
+	addRows(r.Chain, "", 0)
+	return []string{"stage", "species", "evolves_from", "conditions", "caught"}, rows
+}
+
+func commandEvolutions(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("you must supply one pokemon name")
+	}
+
+	// Chains are by species, so caught alternate forms count for their species
+	name := args[0]
+	caught := make(map[string]bool)
+	for _, pokemon := range env.Config.Pokedex {
+		caught[pokemon.SpeciesName()] = true
+		if pokemon.Name == name {
+			name = pokemon.SpeciesName()
+		}
+	}
+
+	chain, err := env.Config.GetEvolutionChainContext(ctx, name)
+	if err != nil {
+		return err
+	}
+	return render(env, evolutionsResult{Chain: newEvolutionStage(chain.Chain, caught)})
+}
@@ -0,0 +1,122 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+func TestCommandEvolutions(t *testing.T) {
+	t.Parallel()
+	stubs := map[string]string{
+		"/api/v2/pokemon-species/pikachu": `{
+			"name": "pikachu",
+			"evolution_chain": {"url": "https://pokeapi.co/api/v2/evolution-chain/10/"}
+		}`,
+		"/api/v2/evolution-chain/10/": `{
+			"id": 10,
+			"chain": {
+				"is_baby": true,
+				"species": {"name": "pichu"},
+				"evolution_details": [],
+				"evolves_to": [{
+					"species": {"name": "pikachu"},
+					"evolution_details": [{"trigger": {"name": "level-up"}, "min_happiness": 220}],
+					"evolves_to": [{
+						"species": {"name": "raichu"},
+						"evolution_details": [
+							{"trigger": {"name": "use-item"}, "item": {"name": "thunder-stone"}},
+							{"trigger": {"name": "use-item"}, "item": {"name": "thunder-stone"}}
+						],
+						"evolves_to": []
+					}]
+				}]
+			}
+		}`,
+		// Branches, told apart by tyrogue's stats
+		"/api/v2/pokemon-species/tyrogue": `{
+			"name": "tyrogue",
+			"evolution_chain": {"url": "https://pokeapi.co/api/v2/evolution-chain/47/"}
+		}`,
+		"/api/v2/evolution-chain/47/": `{
+			"id": 47,
+			"chain": {
+				"is_baby": true,
+				"species": {"name": "tyrogue"},
+				"evolution_details": [],
+				"evolves_to": [
+					{"species": {"name": "hitmonlee"}, "evolution_details": [{"trigger": {"name": "level-up"}, "min_level": 20, "relative_physical_stats": 1}], "evolves_to": []},
+					{"species": {"name": "hitmonchan"}, "evolution_details": [{"trigger": {"name": "level-up"}, "min_level": 20, "relative_physical_stats": -1}], "evolves_to": []},
+					{"species": {"name": "hitmontop"}, "evolution_details": [{"trigger": {"name": "level-up"}, "min_level": 20, "relative_physical_stats": 0}], "evolves_to": []}
+				]
+			}
+		}`,
+	}
+	cases := []struct {
+		name     string
+		pokedex  map[string]pokeapi.Pokemon
+		format   output.Format
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:    "caught stages are marked",
+			pokedex: map[string]pokeapi.Pokemon{"pikachu": {Name: "pikachu"}},
+			args:    []string{"pikachu"},
+			expected: "pichu [baby]\n" +
+				"`-- pikachu (level up with happiness 220) [caught]\n" +
+				"    `-- raichu (use thunder-stone)\n",
+		},
+		{
+			// Forms are looked up, and marked, by their species
+			name:     "caught form",
+			pokedex:  map[string]pokeapi.Pokemon{"pikachu-rock-star": {Name: "pikachu-rock-star", Species: pokeapi.NamedAPIResource{Name: "pikachu"}}},
+			args:     []string{"pikachu-rock-star"},
+			expected: "`-- pikachu (level up with happiness 220) [caught]\n",
+		},
+		{
+			name: "branches",
+			args: []string{"tyrogue"},
+			expected: "tyrogue [baby]\n" +
+				"|-- hitmonlee (level 20, attack > defense)\n" +
+				"|-- hitmonchan (level 20, attack < defense)\n" +
+				"`-- hitmontop (level 20, attack = defense)\n",
+		},
+		{
+			name:   "table",
+			format: output.Table,
+			args:   []string{"pikachu"},
+			expected: "STAGE  SPECIES  EVOLVES_FROM  CONDITIONS                   CAUGHT\n" +
+				"1      pichu                                               false\n" +
+				"2      pikachu  pichu         level up with happiness 220  false\n" +
+				"3      raichu   pikachu       use thunder-stone            false\n",
+		},
+		{
+			name:    "unknown pokemon",
+			args:    []string{"missingno"},
+			wantErr: true,
+		},
+		{
+			name:    "no pokemon",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, _ := newTestEnv(t, &pokeapi.Config{Client: stubAPIClient(stubs), Pokedex: c.pokedex, Output: c.format})
+			err := commandEvolutions(context.Background(), env, c.args)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Expected error %v, got: %v", c.wantErr, err)
+			}
+			if !strings.Contains(stdout.String(), c.expected) {
+				t.Errorf("Expected output to contain:\n%v\ngot:\n%v", c.expected, stdout.String())
+			}
+		})
+	}
+}
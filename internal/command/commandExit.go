@@ -1,15 +1,19 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
-
-	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 )
 
+// Returned (wrapped) by ExecuteCommand when the user asks to quit. The caller
+// owns shutting down - saving the Pokedex and stopping the cache - so exit,
+// EOF and signals all go through the same path rather than exit calling
+// os.Exit out from under everyone.
+var ErrExit = errors.New("exit requested")
+
 // commandfunctions
-func commandExit(config *pokeapi.Config, _ []string) error {
-	fmt.Println("Closing the Pokedex... Goodbye!")
-	os.Exit(0)
-	return nil
+func commandExit(ctx context.Context, env *Env, _ []string) error {
+	fmt.Fprintln(env.Stdout, "Closing the Pokedex... Goodbye!")
+	return ErrExit
 }
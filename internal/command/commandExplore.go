@@ -1,12 +1,33 @@
 package command
 
 import (
+	"context"
 	"fmt"
-
-	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+	"io"
 )
 
-func commandExplore(conf *pokeapi.Config, args []string) error {
+// The pokemon that can be encountered in a location area
+type exploreResult struct {
+	LocationArea string   `json:"location_area"`
+	Pokemon      []string `json:"pokemon"`
+}
+
+func (r exploreResult) WriteText(w io.Writer) error {
+	for _, name := range r.Pokemon {
+		fmt.Fprintln(w, name)
+	}
+	return nil
+}
+
+func (r exploreResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Pokemon))
+	for _, name := range r.Pokemon {
+		rows = append(rows, []string{name})
+	}
+	return []string{"pokemon"}, rows
+}
+
+func commandExplore(ctx context.Context, env *Env, args []string) error {
 
 	// Check input args - we need one and only one
 	if len(args) != 1 {
@@ -16,13 +37,14 @@ func commandExplore(conf *pokeapi.Config, args []string) error {
 		return fmt.Errorf("blank location-area name supplied")
 	}
 
-	locationArea, err := conf.GetLocationArea(args[0])
+	locationArea, err := env.Config.GetLocationAreaContext(ctx, args[0])
 	if err != nil {
 		return err
 	}
 
+	result := exploreResult{LocationArea: locationArea.Name, Pokemon: []string{}}
 	for _, encounter := range locationArea.Pokemon_Encounters {
-		fmt.Println(encounter.Pokemon.Name)
+		result.Pokemon = append(result.Pokemon, encounter.Pokemon.Name)
 	}
-	return nil
+	return render(env, result)
 }
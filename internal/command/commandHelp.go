@@ -1,20 +1,88 @@
 package command
 
 import (
+	"context"
 	"fmt"
-
-	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
 )
 
-func commandHelp(config *pokeapi.Config, _ []string) error {
+// help on its own lists every command by category, help <command> shows
+// the details for one command.
+func commandHelp(ctx context.Context, env *Env, args []string) error {
+	supportedCommands := GetSupportedCommands()
+	if len(args) > 0 {
+		cmd, exists := lookupCommand(supportedCommands, args[0])
+		if !exists {
+			return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+		}
+		return writeCommandHelp(env.Stdout, cmd)
+	}
+
 	// Print welcome and  usage instructions for our supportedCommands
-	fmt.Println("\nWelcome to the Pokedex!")
-	fmt.Println("Usage:")
-	fmt.Println()
-	// Get map of supportedcommands with function
-	for _, cmd := range GetSupportedCommands() {
-		fmt.Printf("%s: %s\n", cmd.Name, cmd.Description)
-	}
-	fmt.Println()
+	fmt.Fprintln(env.Stdout, "\nWelcome to the Pokedex!")
+	fmt.Fprintln(env.Stdout, "Usage:")
+	fmt.Fprintln(env.Stdout)
+
+	// Map order is random so sort by name within each category
+	names := make([]string, 0, len(supportedCommands))
+	for name := range supportedCommands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, category := range categories {
+		fmt.Fprintf(env.Stdout, "%s:\n", titleCase(string(category)))
+		for _, name := range names {
+			cmd := supportedCommands[name]
+			if cmd.Category == category {
+				fmt.Fprintf(env.Stdout, "  %s: %s\n", cmd.Name, cmd.Description)
+			}
+		}
+		fmt.Fprintln(env.Stdout)
+	}
+	fmt.Fprintln(env.Stdout, "Use 'help <command>' for usage, arguments and examples.")
+	fmt.Fprintln(env.Stdout)
 	return nil
 }
+
+func writeCommandHelp(w io.Writer, cmd cliCommand) error {
+	fmt.Fprintf(w, "%s - %s\n\n", cmd.Name, cmd.Description)
+
+	usage := cmd.Usage
+	if usage == "" {
+		usage = cmd.Name
+	}
+	fmt.Fprintf(w, "Usage:\n  %s\n", usage)
+
+	if len(cmd.Args) > 0 {
+		fmt.Fprintln(w, "\nArguments:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, arg := range cmd.Args {
+			fmt.Fprintf(tw, "  %s\t%s\n", arg.Name, arg.Description)
+		}
+		tw.Flush()
+	}
+
+	if len(cmd.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, example := range cmd.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+	return nil
+}
+
+// "exploration" -> "Exploration"
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
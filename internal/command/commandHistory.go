@@ -0,0 +1,64 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/history"
+)
+
+// Numbered lines of history, as listed by the history command
+type historyResult struct {
+	Entries []history.Entry `json:"entries"`
+}
+
+func (r historyResult) WriteText(w io.Writer) error {
+	for _, e := range r.Entries {
+		fmt.Fprintf(w, "%5d  %s\n", e.Index, e.Line)
+	}
+	return nil
+}
+
+func (r historyResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Entries))
+	for _, e := range r.Entries {
+		rows = append(rows, []string{strconv.Itoa(e.Index), e.Line})
+	}
+	return []string{"index", "command"}, rows
+}
+
+// history [count] | search <text>. Re-running is done by the REPL with
+// !-references, see history.Expand.
+func commandHistory(ctx context.Context, env *Env, args []string) error {
+	if env.History == nil {
+		return fmt.Errorf("history is only available at the prompt")
+	}
+
+	entries := env.History.Entries()
+	switch {
+	case len(args) == 0:
+	case args[0] == "search":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: history search <text>")
+		}
+		entries = env.History.Search(strings.Join(args[1:], " "))
+	case len(args) == 1:
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 0 {
+			return fmt.Errorf("invalid count %v: use history [count] | search <text>", args[0])
+		}
+		if count < len(entries) {
+			entries = entries[len(entries)-count:]
+		}
+	default:
+		return fmt.Errorf("usage: history [count] | search <text>")
+	}
+
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	return render(env, historyResult{Entries: entries})
+}
@@ -1,36 +1,119 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 )
 
-func commandInspect(conf *pokeapi.Config, PokemonName []string) error {
+// The parts of a caught pokemon's entry that inspect shows. Genus and
+// FlavorText come from its species and are empty if that couldn't be fetched.
+type inspectResult struct {
+	Name       string        `json:"name"`
+	Genus      string        `json:"genus,omitempty"`
+	Height     int           `json:"height"`
+	Weight     int           `json:"weight"`
+	Stats      []inspectStat `json:"stats"`
+	Types      []string      `json:"types"`
+	FlavorText string        `json:"flavor_text,omitempty"`
+}
+
+type inspectStat struct {
+	Name     string `json:"name"`
+	BaseStat int    `json:"base_stat"`
+}
+
+func newInspectResult(pokemon pokeapi.Pokemon) inspectResult {
+	r := inspectResult{
+		Name:   pokemon.Name,
+		Height: pokemon.Height,
+		Weight: pokemon.Weight,
+		Stats:  []inspectStat{},
+		Types:  []string{},
+	}
+	for _, s := range pokemon.Stats {
+		r.Stats = append(r.Stats, inspectStat{Name: s.Stat_info.Name, BaseStat: s.Base_stat})
+	}
+	for _, t := range pokemon.Types {
+		r.Types = append(r.Types, t.Type.Name)
+	}
+	return r
+}
+
+func (r inspectResult) WriteText(w io.Writer) error {
+	// Print the fields we care about
+	fmt.Fprintf(w, "Name: %v\n", r.Name)
+	if r.Genus != "" {
+		fmt.Fprintf(w, "Genus: %v\n", r.Genus)
+	}
+	fmt.Fprintf(w, "Height: %v\n", r.Height)
+	fmt.Fprintf(w, "Weight: %v\n", r.Weight)
+	fmt.Fprintln(w, "Stats:")
+	// Loop through stats
+	for _, s := range r.Stats {
+		fmt.Fprintf(w, "  -%v: %v\n", s.Name, s.BaseStat)
+	}
+	// Loop through Types
+	fmt.Fprintln(w, "Types:")
+	for _, t := range r.Types {
+		fmt.Fprintf(w, "  - %v\n", t)
+	}
+	if r.FlavorText != "" {
+		fmt.Fprintf(w, "\n%v\n", r.FlavorText)
+	}
+	return nil
+}
+
+// One row per field, stats get a row each
+func (r inspectResult) Table() ([]string, [][]string) {
+	rows := [][]string{
+		{"name", r.Name},
+		{"height", strconv.Itoa(r.Height)},
+		{"weight", strconv.Itoa(r.Weight)},
+	}
+	for _, s := range r.Stats {
+		rows = append(rows, []string{s.Name, strconv.Itoa(s.BaseStat)})
+	}
+	rows = append(rows, []string{"types", strings.Join(r.Types, ",")})
+	if r.Genus != "" {
+		rows = append(rows, []string{"genus", r.Genus})
+	}
+	if r.FlavorText != "" {
+		rows = append(rows, []string{"flavor_text", r.FlavorText})
+	}
+	return []string{"field", "value"}, rows
+}
+
+func commandInspect(ctx context.Context, env *Env, PokemonName []string) error {
 	if len(PokemonName) < 1 {
 		return fmt.Errorf("you must supply a pokemon to inspect")
 	}
 
-	pokemon, err := conf.InspectPokemon(PokemonName[0])
+	pokemon, err := env.Config.InspectPokemon(PokemonName[0])
 
 	//if error in finding pokemon, or if it does not exist in the pokedex
 	if err != nil {
 		return err
 	}
 
-	// Print the fields we care about
-	fmt.Printf("Name: %v\n", pokemon.Name)
-	fmt.Printf("Height: %v\n", pokemon.Height)
-	fmt.Printf("Weight: %v\n", pokemon.Weight)
-	fmt.Println("Stats:")
-	// Loop through stats
-	for _, s := range pokemon.Stats {
-		fmt.Printf("  -%v: %v\n", s.Stat_info.Name, s.Base_stat)
+	result := newInspectResult(pokemon)
+
+	// Species data is a nice extra - if the API can't be reached we still
+	// show what's in the Pokedex
+	species, err := env.Config.GetPokemonSpeciesContext(ctx, pokemon.SpeciesName())
+	if errors.Is(err, context.Canceled) {
+		return err
 	}
-	// Loop through Types
-	fmt.Println("Types:")
-	for _, t := range pokemon.Types {
-		fmt.Printf("  - %v\n", t.Type.Name)
+	if err != nil {
+		fmt.Fprintf(env.Stderr, "could not get species for %v: %v\n", pokemon.Name, err)
+	} else {
+		result.Genus = species.Genus(pokeapi.DefaultLanguage)
+		result.FlavorText = species.FlavorText(pokeapi.DefaultLanguage)
 	}
-	return nil
+	return render(env, result)
 }
@@ -1,24 +1,43 @@
 package command
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 )
 
-func commandMap(conf *pokeapi.Config, _ []string) error {
+// A page of location areas, shown by map and mapb
+type locationAreaPage struct {
+	LocationAreas []pokeapi.NamedAPIResource `json:"location_areas"`
+}
+
+func (p locationAreaPage) WriteText(w io.Writer) error {
+	for _, locarea := range p.LocationAreas {
+		fmt.Fprintln(w, locarea.Name)
+	}
+	return nil
+}
+
+func (p locationAreaPage) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(p.LocationAreas))
+	for _, locarea := range p.LocationAreas {
+		rows = append(rows, []string{locarea.Name, locarea.Url})
+	}
+	return []string{"name", "url"}, rows
+}
+
+func commandMap(ctx context.Context, env *Env, _ []string) error {
 	//Default behaviour is to return batches of 20 location-areas.
 	//Use the next URL stored in conf if it exists and update next/previous
 	// Else default to the base URL and update next
 
-	locationAreas, err := conf.GetLocationAreas(conf.Next)
+	locationAreas, err := env.Config.GetLocationAreasContext(ctx, env.Config.Next)
 	if err != nil {
 		return err
 	}
 
-	//Now fetch the names of the location-areas within results slice
-	for _, locarea := range locationAreas.Results {
-		fmt.Println(locarea.Name)
-	}
-	return nil
+	//Now print the names of the location-areas within results slice
+	return render(env, locationAreaPage{LocationAreas: locationAreas.Results})
 }
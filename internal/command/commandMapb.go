@@ -1,26 +1,23 @@
 package command
 
 import (
+	"context"
 	"fmt"
-
-	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 )
 
-func commandMapb(conf *pokeapi.Config, _ []string) error {
+func commandMapb(ctx context.Context, env *Env, _ []string) error {
 	//in case we are already on first page (no previous)
-	if conf.Previous == nil || conf.Previous.Path == "" {
-		fmt.Println("you're on the first page.")
+	if env.Config.Previous == nil || env.Config.Previous.Path == "" {
+		// stderr so it doesn't end up in json/yaml output
+		fmt.Fprintln(env.Stderr, "you're on the first page.")
 		return nil
 	}
 
-	locationAreas, err := conf.GetLocationAreas(conf.Previous)
+	locationAreas, err := env.Config.GetLocationAreasContext(ctx, env.Config.Previous)
 	if err != nil {
 		return err
 	}
 
 	//Print the location area names
-	for _, locarea := range locationAreas.Results {
-		fmt.Println(locarea.Name)
-	}
-	return nil
+	return render(env, locationAreaPage{LocationAreas: locationAreas.Results})
 }
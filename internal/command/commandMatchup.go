@@ -0,0 +1,201 @@
+package command
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+// A pokemon or a type, and the types it defends with
+type matchupSide struct {
+	Name  string   `json:"name"`
+	Types []string `json:"types"`
+}
+
+// e.g "gyarados (water, flying)", or just "fire" for a type
+func (s matchupSide) String() string {
+	if len(s.Types) == 1 && s.Types[0] == s.Name {
+		return s.Name
+	}
+	return fmt.Sprintf("%v (%v)", s.Name, strings.Join(s.Types, ", "))
+}
+
+type typeMultiplier struct {
+	Type       string  `json:"type"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// x4, x0.5 etc
+func formatMultiplier(m float64) string {
+	return "x" + strconv.FormatFloat(m, 'g', -1, 64)
+}
+
+// What matchup shows for one pokemon or type - the attacking types that
+// don't do normal damage to it
+type matchupResult struct {
+	Name        string           `json:"name"`
+	Types       []string         `json:"types"`
+	Weaknesses  []typeMultiplier `json:"weaknesses"`
+	Resistances []typeMultiplier `json:"resistances"`
+	Immunities  []typeMultiplier `json:"immunities"`
+}
+
+func newMatchupResult(side matchupSide, types []pokeapi.Type) matchupResult {
+	r := matchupResult{
+		Name:        side.Name,
+		Types:       side.Types,
+		Weaknesses:  []typeMultiplier{},
+		Resistances: []typeMultiplier{},
+		Immunities:  []typeMultiplier{},
+	}
+	for attacking, m := range pokeapi.DefensiveChart(types) {
+		tm := typeMultiplier{Type: attacking, Multiplier: m}
+		switch {
+		case m == 0:
+			r.Immunities = append(r.Immunities, tm)
+		case m < 1:
+			r.Resistances = append(r.Resistances, tm)
+		default:
+			r.Weaknesses = append(r.Weaknesses, tm)
+		}
+	}
+	// Map order is random - biggest multiplier first, then by name
+	for _, list := range [][]typeMultiplier{r.Weaknesses, r.Resistances, r.Immunities} {
+		slices.SortFunc(list, func(a, b typeMultiplier) int {
+			return cmp.Or(cmp.Compare(b.Multiplier, a.Multiplier), cmp.Compare(a.Type, b.Type))
+		})
+	}
+	return r
+}
+
+func (r matchupResult) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, matchupSide{Name: r.Name, Types: r.Types})
+	fmt.Fprintf(w, "Weak to: %v\n", joinMultipliers(r.Weaknesses))
+	fmt.Fprintf(w, "Resists: %v\n", joinMultipliers(r.Resistances))
+	fmt.Fprintf(w, "Immune to: %v\n", joinMultipliers(r.Immunities))
+	return nil
+}
+
+// "ground x2, rock x2" or "none"
+func joinMultipliers(list []typeMultiplier) string {
+	if len(list) == 0 {
+		return "none"
+	}
+	parts := make([]string, 0, len(list))
+	for _, tm := range list {
+		parts = append(parts, tm.Type+" "+formatMultiplier(tm.Multiplier))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r matchupResult) Table() ([]string, [][]string) {
+	var rows [][]string
+	add := func(relation string, list []typeMultiplier) {
+		for _, tm := range list {
+			rows = append(rows, []string{relation, tm.Type, formatMultiplier(tm.Multiplier)})
+		}
+	}
+	add("weak", r.Weaknesses)
+	add("resists", r.Resistances)
+	add("immune", r.Immunities)
+	return []string{"relation", "type", "multiplier"}, rows
+}
+
+// One of a side's types attacking the other side
+type matchupAttack struct {
+	Attacker   string  `json:"attacker"`
+	Type       string  `json:"type"`
+	Defender   string  `json:"defender"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// What matchup <a> vs <b> shows - each side's types attacking the other
+type versusResult struct {
+	Subject  matchupSide     `json:"subject"`
+	Opponent matchupSide     `json:"opponent"`
+	Attacks  []matchupAttack `json:"attacks"`
+}
+
+func newVersusResult(subject, opponent matchupSide, subjectTypes, opponentTypes []pokeapi.Type) versusResult {
+	r := versusResult{Subject: subject, Opponent: opponent}
+	attack := func(attacker, defender matchupSide, defending []pokeapi.Type) {
+		for _, t := range attacker.Types {
+			r.Attacks = append(r.Attacks, matchupAttack{
+				Attacker:   attacker.Name,
+				Type:       t,
+				Defender:   defender.Name,
+				Multiplier: pokeapi.Effectiveness(t, defending),
+			})
+		}
+	}
+	attack(subject, opponent, opponentTypes)
+	attack(opponent, subject, subjectTypes)
+	return r
+}
+
+func (r versusResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%v vs %v\n", r.Subject, r.Opponent)
+	for _, a := range r.Attacks {
+		fmt.Fprintf(w, "  %v (%v) -> %v: %v\n", a.Type, a.Attacker, a.Defender, formatMultiplier(a.Multiplier))
+	}
+	return nil
+}
+
+func (r versusResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Attacks))
+	for _, a := range r.Attacks {
+		rows = append(rows, []string{a.Attacker, a.Type, a.Defender, formatMultiplier(a.Multiplier)})
+	}
+	return []string{"attacker", "type", "defender", "multiplier"}, rows
+}
+
+// matchup <pokemon|type> shows what the pokemon or type is weak to, resists
+// and is immune to. matchup <a> vs <b> shows how each side's types hit the other.
+func commandMatchup(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 && (len(args) != 3 || args[1] != "vs") {
+		return fmt.Errorf("usage: matchup <pokemon|type> [vs <pokemon|type>]")
+	}
+
+	subject, subjectTypes, err := resolveMatchupSide(ctx, env, args[0])
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return render(env, newMatchupResult(subject, subjectTypes))
+	}
+
+	opponent, opponentTypes, err := resolveMatchupSide(ctx, env, args[2])
+	if err != nil {
+		return err
+	}
+	return render(env, newVersusResult(subject, opponent, subjectTypes, opponentTypes))
+}
+
+// Work out whether name is a type or a pokemon, and fetch the types it has.
+// Caught pokemon come from the Pokedex, others from the API.
+func resolveMatchupSide(ctx context.Context, env *Env, name string) (matchupSide, []pokeapi.Type, error) {
+	side := matchupSide{Name: name, Types: []string{name}}
+	if !slices.Contains(pokeapi.BattleTypes, name) {
+		pokemon, caught := env.Config.Pokedex[name]
+		if !caught {
+			var err error
+			pokemon, err = env.Config.GetPokemonContext(ctx, name)
+			if err != nil {
+				return matchupSide{}, nil, fmt.Errorf("%v is not a type and could not get it as a pokemon: %w", name, err)
+			}
+		}
+		side.Types = pokemon.TypeNames()
+	}
+
+	types, err := env.Config.GetTypesContext(ctx, side.Types)
+	if err != nil {
+		return matchupSide{}, nil, err
+	}
+	return side, types, nil
+}
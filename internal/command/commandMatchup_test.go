@@ -0,0 +1,111 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+func TestCommandMatchup(t *testing.T) {
+	t.Parallel()
+	stubs := map[string]string{
+		// Only the defending side of each type, which is all matchup uses
+		"/api/v2/type/electric": `{
+			"name": "electric",
+			"damage_relations": {
+				"half_damage_from": [{"name": "electric"}, {"name": "flying"}, {"name": "steel"}],
+				"double_damage_from": [{"name": "ground"}]
+			}
+		}`,
+		"/api/v2/type/water": `{
+			"name": "water",
+			"damage_relations": {
+				"half_damage_from": [{"name": "fire"}, {"name": "water"}, {"name": "ice"}, {"name": "steel"}],
+				"double_damage_from": [{"name": "electric"}, {"name": "grass"}]
+			}
+		}`,
+		"/api/v2/type/flying": `{
+			"name": "flying",
+			"damage_relations": {
+				"no_damage_from": [{"name": "ground"}],
+				"half_damage_from": [{"name": "grass"}, {"name": "fighting"}, {"name": "bug"}],
+				"double_damage_from": [{"name": "electric"}, {"name": "ice"}, {"name": "rock"}]
+			}
+		}`,
+		"/api/v2/pokemon/gyarados": `{
+			"name": "gyarados",
+			"types": [{"slot": 2, "type": {"name": "flying"}}, {"slot": 1, "type": {"name": "water"}}]
+		}`,
+	}
+	pokedex := map[string]pokeapi.Pokemon{
+		"pikachu": {Name: "pikachu", Types: []pokeapi.PokemonType{{Slot: 1, Type: pokeapi.NamedAPIResource{Name: "electric"}}}},
+	}
+	cases := []struct {
+		name     string
+		format   output.Format
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			// gyarados isn't caught so comes from the API
+			name: "dual type pokemon",
+			args: []string{"gyarados"},
+			expected: "gyarados (water, flying)\n" +
+				"Weak to: electric x4, rock x2\n" +
+				"Resists: bug x0.5, fighting x0.5, fire x0.5, steel x0.5, water x0.5\n" +
+				"Immune to: ground x0\n",
+		},
+		{
+			name: "type",
+			args: []string{"electric"},
+			expected: "electric\n" +
+				"Weak to: ground x2\n" +
+				"Resists: electric x0.5, flying x0.5, steel x0.5\n" +
+				"Immune to: none\n",
+		},
+		{
+			name: "versus",
+			args: []string{"pikachu", "vs", "gyarados"},
+			expected: "pikachu (electric) vs gyarados (water, flying)\n" +
+				"  electric (pikachu) -> gyarados: x4\n" +
+				"  water (gyarados) -> pikachu: x1\n" +
+				"  flying (gyarados) -> pikachu: x0.5\n",
+		},
+		{
+			name:   "versus table",
+			format: output.Table,
+			args:   []string{"pikachu", "vs", "water"},
+			expected: "ATTACKER  TYPE      DEFENDER  MULTIPLIER\n" +
+				"pikachu   electric  water     x2\n" +
+				"water     water     pikachu   x1\n",
+		},
+		{
+			name:    "unknown pokemon",
+			args:    []string{"missingno"},
+			wantErr: true,
+		},
+		{
+			name:    "missing vs",
+			args:    []string{"pikachu", "gyarados"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, _ := newTestEnv(t, &pokeapi.Config{Client: stubAPIClient(stubs), Pokedex: pokedex, Output: c.format})
+			err := commandMatchup(context.Background(), env, c.args)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Expected error %v, got: %v", c.wantErr, err)
+			}
+			if !strings.Contains(stdout.String(), c.expected) {
+				t.Errorf("Expected output to contain:\n%v\ngot:\n%v", c.expected, stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package command
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+// How many move details to fetch at once. The client's rate limiter still
+// paces the requests, this just stops a big learnset waiting on each in turn.
+const moveFetchWorkers = 8
+
+// What moves can sort the learnset by
+var moveSortKeys = []string{"level", "name", "power", "accuracy", "pp", "type", "class"}
+
+// PokeAPI's move learn methods, the common ones first. The rest only turn up
+// for a few pokemon in spin-off or older games.
+var moveLearnMethods = []string{
+	"level-up", "machine", "egg", "tutor",
+	"stadium-surfing-pikachu", "light-ball-egg", "colosseum-purification",
+	"xd-shadow", "xd-purification", "form-change", "zygarde-cube",
+}
+
+// A pokemon's learnset in one version group
+type movesResult struct {
+	Pokemon      string        `json:"pokemon"`
+	VersionGroup string        `json:"version_group"`
+	Moves        []learnedMove `json:"moves"`
+}
+
+// One way of learning a move, with the move's details. Power, Accuracy and
+// PP are null when they don't apply or the move couldn't be fetched.
+type learnedMove struct {
+	Name     string `json:"name"`
+	Method   string `json:"method"`
+	Level    int    `json:"level"`
+	Type     string `json:"type"`
+	Class    string `json:"damage_class"`
+	Power    *int   `json:"power"`
+	Accuracy *int   `json:"accuracy"`
+	PP       *int   `json:"pp"`
+	Effect   string `json:"effect"`
+}
+
+func (m learnedMove) row() []string {
+	level := "-"
+	if m.Method == "level-up" {
+		level = strconv.Itoa(m.Level)
+	}
+	return []string{level, m.Name, m.Type, m.Class, intOrDash(m.Power), intOrDash(m.Accuracy), intOrDash(m.PP), m.Method, m.Effect}
+}
+
+func intOrDash(n *int) string {
+	if n == nil {
+		return "-"
+	}
+	return strconv.Itoa(*n)
+}
+
+var movesHeader = []string{"level", "move", "type", "class", "power", "accuracy", "pp", "method", "effect"}
+
+func (r movesResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "%v's moves in %v:\n", r.Pokemon, r.VersionGroup)
+	if len(r.Moves) == 0 {
+		fmt.Fprintln(w, "  none")
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(movesHeader, "\t")))
+	for _, m := range r.Moves {
+		fmt.Fprintln(tw, strings.Join(m.row(), "\t"))
+	}
+	return tw.Flush()
+}
+
+func (r movesResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Moves))
+	for _, m := range r.Moves {
+		rows = append(rows, m.row())
+	}
+	return movesHeader, rows
+}
+
+type movesOptions struct {
+	pokemon string
+	method  string
+	version string
+	sort    string
+}
+
+// Accepts --flag value and --flag=value, in any order around the pokemon name
+func parseMovesArgs(args []string) (movesOptions, error) {
+	opts := movesOptions{sort: "level"}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			if opts.pokemon != "" {
+				return movesOptions{}, fmt.Errorf("you must supply one pokemon name")
+			}
+			opts.pokemon = arg
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return movesOptions{}, fmt.Errorf("--%v needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "method":
+			if !slices.Contains(moveLearnMethods, value) {
+				return movesOptions{}, fmt.Errorf("unknown learn method %v - use one of %v", value, strings.Join(moveLearnMethods, ", "))
+			}
+			opts.method = value
+		case "version":
+			opts.version = value
+		case "sort":
+			if !slices.Contains(moveSortKeys, value) {
+				return movesOptions{}, fmt.Errorf("can't sort by %v - use one of %v", value, strings.Join(moveSortKeys, ", "))
+			}
+			opts.sort = value
+		default:
+			return movesOptions{}, fmt.Errorf("unknown option --%v", name)
+		}
+	}
+	if opts.pokemon == "" {
+		return movesOptions{}, fmt.Errorf("you must supply one pokemon name")
+	}
+	return opts, nil
+}
+
+// moves <pokemon> shows what the pokemon learns in the latest version group,
+// or the one given with --version. Learnsets aren't saved with the Pokedex so
+// this always goes to the cache or API, even for caught pokemon.
+func commandMoves(ctx context.Context, env *Env, args []string) error {
+	opts, err := parseMovesArgs(args)
+	if err != nil {
+		return err
+	}
+
+	pokemon, err := env.Config.GetPokemonContext(ctx, opts.pokemon)
+	if err != nil {
+		return err
+	}
+
+	groups := pokemon.MoveVersionGroups()
+	if len(groups) == 0 {
+		return fmt.Errorf("%v doesn't learn any moves", pokemon.Name)
+	}
+	var groupNames []string
+	for _, g := range groups {
+		groupNames = append(groupNames, g.Name)
+	}
+	if opts.version == "" {
+		opts.version = groupNames[len(groupNames)-1]
+	} else if !slices.Contains(groupNames, opts.version) {
+		return fmt.Errorf("%v has no moves in %v - try one of %v", pokemon.Name, opts.version, strings.Join(groupNames, ", "))
+	}
+
+	result := movesResult{Pokemon: pokemon.Name, VersionGroup: opts.version, Moves: []learnedMove{}}
+	for _, pm := range pokemon.Moves {
+		for _, d := range pm.Version_Group_Details {
+			if d.Version_Group.Name != opts.version {
+				continue
+			}
+			if opts.method != "" && d.Move_Learn_Method.Name != opts.method {
+				continue
+			}
+			result.Moves = append(result.Moves, learnedMove{
+				Name:   pm.Move.Name,
+				Method: d.Move_Learn_Method.Name,
+				Level:  d.Level_Learned_At,
+			})
+		}
+	}
+
+	// Fill in each move's details. One that can't be fetched is still listed.
+	// Errors are written afterwards so they come out in a steady order.
+	errs := make([]error, len(result.Moves))
+	sem := make(chan struct{}, moveFetchWorkers)
+	var wg sync.WaitGroup
+	for i := range result.Moves {
+		m := &result.Moves[i]
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			move, err := env.Config.GetMoveContext(ctx, m.Name)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			m.Type = move.Type.Name
+			m.Class = move.Damage_Class.Name
+			m.Power = move.Power
+			m.Accuracy = move.Accuracy
+			m.PP = move.PP
+			m.Effect = move.ShortEffect(pokeapi.DefaultLanguage)
+		})
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(env.Stderr, "could not get move %v: %v\n", result.Moves[i].Name, err)
+		}
+	}
+
+	slices.SortStableFunc(result.Moves, moveOrder(opts.sort))
+	return render(env, result)
+}
+
+// Compare learned moves by key, falling back on the name. Level puts level-up
+// moves first, lowest level first. Power, accuracy and PP go highest first,
+// with moves that have none last.
+func moveOrder(key string) func(a, b learnedMove) int {
+	byName := func(a, b learnedMove) int { return cmp.Compare(a.Name, b.Name) }
+	highestFirst := func(field func(learnedMove) *int) func(a, b learnedMove) int {
+		return func(a, b learnedMove) int {
+			x, y := field(a), field(b)
+			switch {
+			case x == nil && y == nil:
+				return byName(a, b)
+			case x == nil:
+				return 1
+			case y == nil:
+				return -1
+			}
+			return cmp.Or(cmp.Compare(*y, *x), byName(a, b))
+		}
+	}
+
+	switch key {
+	case "power":
+		return highestFirst(func(m learnedMove) *int { return m.Power })
+	case "accuracy":
+		return highestFirst(func(m learnedMove) *int { return m.Accuracy })
+	case "pp":
+		return highestFirst(func(m learnedMove) *int { return m.PP })
+	case "type":
+		return func(a, b learnedMove) int { return cmp.Or(cmp.Compare(a.Type, b.Type), byName(a, b)) }
+	case "class":
+		return func(a, b learnedMove) int { return cmp.Or(cmp.Compare(a.Class, b.Class), byName(a, b)) }
+	case "name":
+		return byName
+	}
+	return func(a, b learnedMove) int {
+		aLevelUp, bLevelUp := a.Method == "level-up", b.Method == "level-up"
+		if aLevelUp != bLevelUp {
+			if aLevelUp {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(cmp.Compare(a.Method, b.Method), cmp.Compare(a.Level, b.Level), byName(a, b))
+	}
+}
@@ -0,0 +1,144 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+func TestCommandMoves(t *testing.T) {
+	t.Parallel()
+	stubs := map[string]string{
+		// Version groups out of order, as the API gives them. growl isn't
+		// stubbed, so can't be fetched.
+		"/api/v2/pokemon/pikachu": `{
+			"name": "pikachu",
+			"moves": [
+				{"move": {"name": "thunderbolt"}, "version_group_details": [
+					{"level_learned_at": 0, "move_learn_method": {"name": "machine"}, "version_group": {"name": "sword-shield", "url": "https://pokeapi.co/api/v2/version-group/20/"}},
+					{"level_learned_at": 26, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "red-blue", "url": "https://pokeapi.co/api/v2/version-group/1/"}}
+				]},
+				{"move": {"name": "thunder-shock"}, "version_group_details": [
+					{"level_learned_at": 1, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "red-blue", "url": "https://pokeapi.co/api/v2/version-group/1/"}},
+					{"level_learned_at": 1, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "sword-shield", "url": "https://pokeapi.co/api/v2/version-group/20/"}}
+				]},
+				{"move": {"name": "growl"}, "version_group_details": [
+					{"level_learned_at": 0, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "red-blue", "url": "https://pokeapi.co/api/v2/version-group/1/"}},
+					{"level_learned_at": 5, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "sword-shield", "url": "https://pokeapi.co/api/v2/version-group/20/"}}
+				]}
+			]
+		}`,
+		"/api/v2/move/thunder-shock": `{
+			"name": "thunder-shock",
+			"power": 40, "accuracy": 100, "pp": 30, "effect_chance": 10,
+			"damage_class": {"name": "special"},
+			"type": {"name": "electric"},
+			"effect_entries": [{"short_effect": "Has a $effect_chance% chance to paralyze the target.", "language": {"name": "en"}}]
+		}`,
+		"/api/v2/move/thunderbolt": `{
+			"name": "thunderbolt",
+			"power": 90, "accuracy": 100, "pp": 15, "effect_chance": 10,
+			"damage_class": {"name": "special"},
+			"type": {"name": "electric"},
+			"effect_entries": [{"short_effect": "Has a $effect_chance% chance to paralyze the target.", "language": {"name": "en"}}]
+		}`,
+	}
+	cases := []struct {
+		name     string
+		format   output.Format
+		args     []string
+		expected []string
+		stderr   string
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			// Latest version group by default, level-up moves first by level
+			name: "defaults",
+			args: []string{"pikachu"},
+			expected: []string{
+				"pikachu's moves in sword-shield:\n",
+				"LEVEL  MOVE           TYPE      CLASS    POWER  ACCURACY  PP  METHOD    EFFECT\n" +
+					"1      thunder-shock  electric  special  40     100       30  level-up  Has a 10% chance to paralyze the target.\n" +
+					"5      growl                             -      -         -   level-up  \n" +
+					"-      thunderbolt    electric  special  90     100       15  machine   Has a 10% chance to paralyze the target.\n",
+			},
+			stderr: "could not get move growl",
+		},
+		{
+			name:     "method and version",
+			format:   output.Table,
+			args:     []string{"pikachu", "--method", "level-up", "--version=red-blue"},
+			expected: []string{"1      thunder-shock", "\n26     thunderbolt"},
+		},
+		{
+			// Level 0 is learned on evolution, not a missing level
+			name:     "level 0 in json",
+			format:   output.JSON,
+			args:     []string{"pikachu", "--version", "red-blue"},
+			expected: []string{`"name": "growl"`, `"level": 0,`, `"name": "thunder-shock"`},
+		},
+		{
+			name:     "sort by power",
+			format:   output.Table,
+			args:     []string{"--sort", "power", "pikachu"},
+			expected: []string{"\n-      thunderbolt    electric  special  90 ", "\n1      thunder-shock", "\n5      growl"},
+		},
+		{
+			name:    "unknown version",
+			args:    []string{"pikachu", "--version", "gold-silver"},
+			wantErr: true,
+		},
+		{
+			name:    "bad sort",
+			args:    []string{"pikachu", "--sort", "cuteness"},
+			wantErr: true,
+		},
+		{
+			// A typo would otherwise just list no moves
+			name:    "unknown method",
+			args:    []string{"pikachu", "--method", "levelup"},
+			wantErr: true,
+			errMsg:  "use one of level-up, machine, egg, tutor",
+		},
+		{
+			name:    "missing value",
+			args:    []string{"pikachu", "--method"},
+			wantErr: true,
+		},
+		{
+			name:    "no pokemon",
+			args:    []string{"--method", "egg"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, stderr := newTestEnv(t, &pokeapi.Config{Client: stubAPIClient(stubs), Output: c.format})
+			err := commandMoves(context.Background(), env, c.args)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Expected error %v, got: %v", c.wantErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), c.errMsg) {
+				t.Fatalf("Expected error to contain %q, got: %v", c.errMsg, err)
+			}
+			// Each expected chunk must come after the one before, to check the order
+			out := stdout.String()
+			for _, want := range c.expected {
+				i := strings.Index(out, want)
+				if i < 0 {
+					t.Fatalf("Expected output to contain %q in order, got:\n%v", want, stdout.String())
+				}
+				out = out[i+len(want):]
+			}
+			if !strings.Contains(stderr.String(), c.stderr) {
+				t.Errorf("Expected stderr to contain %q, got %q", c.stderr, stderr.String())
+			}
+		})
+	}
+}
@@ -1,16 +1,57 @@
 package command
 
 import (
+	"context"
 	"fmt"
-
-	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
 )
 
-func commandPokedex(conf *pokeapi.Config, _ []string) error {
-	fmt.Println("Your Pokedex:")
-	for key := range conf.Pokedex {
-		fmt.Println("  -", key)
-	}
+// Everything caught so far, in name order
+type pokedexResult struct {
+	Pokemon []pokedexEntry `json:"pokemon"`
+}
 
+type pokedexEntry struct {
+	Name  string   `json:"name"`
+	ID    int      `json:"id"`
+	Types []string `json:"types"`
+}
+
+func (r pokedexResult) WriteText(w io.Writer) error {
+	fmt.Fprintln(w, "Your Pokedex:")
+	for _, p := range r.Pokemon {
+		fmt.Fprintln(w, "  -", p.Name)
+	}
 	return nil
 }
+
+func (r pokedexResult) Table() ([]string, [][]string) {
+	rows := make([][]string, 0, len(r.Pokemon))
+	for _, p := range r.Pokemon {
+		rows = append(rows, []string{p.Name, strconv.Itoa(p.ID), strings.Join(p.Types, ",")})
+	}
+	return []string{"name", "id", "types"}, rows
+}
+
+func commandPokedex(ctx context.Context, env *Env, _ []string) error {
+	// Map order is random so sort by name to keep the output stable
+	names := make([]string, 0, len(env.Config.Pokedex))
+	for key := range env.Config.Pokedex {
+		names = append(names, key)
+	}
+	slices.Sort(names)
+
+	result := pokedexResult{Pokemon: []pokedexEntry{}}
+	for _, name := range names {
+		pokemon := env.Config.Pokedex[name]
+		entry := pokedexEntry{Name: name, ID: pokemon.ID, Types: []string{}}
+		for _, t := range pokemon.Types {
+			entry.Types = append(entry.Types, t.Type.Name)
+		}
+		result.Pokemon = append(result.Pokemon, entry)
+	}
+	return render(env, result)
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"context"
+	"fmt"
+)
+
+// profile [list] | create <name> | switch <name> | delete <name>
+func commandProfile(ctx context.Context, env *Env, args []string) error {
+	if env.Config.Profiles == nil {
+		return fmt.Errorf("profiles are not available")
+	}
+
+	// No subcommand - list profiles
+	if len(args) == 0 || args[0] == "list" {
+		names, err := env.Config.Profiles.List()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(env.Stdout, "Trainer profiles:")
+		for _, name := range names {
+			// Mark the one in use
+			marker := " "
+			if name == env.Config.Profiles.Current {
+				marker = "*"
+			}
+			fmt.Fprintf(env.Stdout, " %s %s\n", marker, name)
+		}
+		return nil
+	}
+
+	if len(args) != 2 {
+		return fmt.Errorf("usage: profile [list] | create <name> | switch <name> | delete <name>")
+	}
+	name := args[1]
+
+	switch args[0] {
+	case "create":
+		err := env.Config.Profiles.Create(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stdout, "Created profile %v. Use 'profile switch %v' to start using it.\n", name, name)
+	case "switch":
+		err := env.Config.SwitchProfile(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stdout, "Switched to profile %v - %v pokemon in your Pokedex.\n", name, len(env.Config.Pokedex))
+	case "delete":
+		err := env.Config.Profiles.Delete(name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env.Stdout, "Deleted profile %v.\n", name)
+	default:
+		return fmt.Errorf("unknown profile subcommand %v: use list, create, switch or delete", args[0])
+	}
+	return nil
+}
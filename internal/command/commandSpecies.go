@@ -0,0 +1,107 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+// What species shows about a pokemon species
+type speciesResult struct {
+	Name        string `json:"name"`
+	Genus       string `json:"genus"`
+	FlavorText  string `json:"flavor_text"`
+	CaptureRate int    `json:"capture_rate"`
+	GrowthRate  string `json:"growth_rate"`
+	Habitat     string `json:"habitat"`
+	Generation  string `json:"generation"`
+	Legendary   bool   `json:"legendary"`
+	Mythical    bool   `json:"mythical"`
+	Baby        bool   `json:"baby"`
+}
+
+func newSpeciesResult(s pokeapi.PokemonSpecies) speciesResult {
+	r := speciesResult{
+		Name:        s.Name,
+		Genus:       s.Genus(pokeapi.DefaultLanguage),
+		FlavorText:  s.FlavorText(pokeapi.DefaultLanguage),
+		CaptureRate: s.Capture_Rate,
+		GrowthRate:  s.Growth_Rate.Name,
+		Generation:  s.Generation.Name,
+		Legendary:   s.Is_Legendary,
+		Mythical:    s.Is_Mythical,
+		Baby:        s.Is_Baby,
+	}
+	if s.Habitat != nil {
+		r.Habitat = s.Habitat.Name
+	}
+	return r
+}
+
+func (r speciesResult) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Name: %v\n", r.Name)
+	if r.Genus != "" {
+		fmt.Fprintf(w, "Genus: %v\n", r.Genus)
+	}
+	fmt.Fprintf(w, "Generation: %v\n", r.Generation)
+	fmt.Fprintf(w, "Habitat: %v\n", valueOr(r.Habitat, "unknown"))
+	fmt.Fprintf(w, "Capture rate: %v/255\n", r.CaptureRate)
+	fmt.Fprintf(w, "Growth rate: %v\n", r.GrowthRate)
+	if r.Legendary {
+		fmt.Fprintln(w, "Legendary!")
+	}
+	if r.Mythical {
+		fmt.Fprintln(w, "Mythical!")
+	}
+	if r.Baby {
+		fmt.Fprintln(w, "Baby pokemon")
+	}
+	if r.FlavorText != "" {
+		fmt.Fprintf(w, "\n%v\n", r.FlavorText)
+	}
+	return nil
+}
+
+func (r speciesResult) Table() ([]string, [][]string) {
+	return []string{"field", "value"}, [][]string{
+		{"name", r.Name},
+		{"genus", r.Genus},
+		{"generation", r.Generation},
+		{"habitat", r.Habitat},
+		{"capture_rate", strconv.Itoa(r.CaptureRate)},
+		{"growth_rate", r.GrowthRate},
+		{"legendary", strconv.FormatBool(r.Legendary)},
+		{"mythical", strconv.FormatBool(r.Mythical)},
+		{"baby", strconv.FormatBool(r.Baby)},
+		{"flavor_text", r.FlavorText},
+	}
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// Works for any pokemon, caught or not. For caught pokemon we use the species
+// saved with them, so alternate forms (e.g deoxys-attack) find theirs.
+func commandSpecies(ctx context.Context, env *Env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("you must supply one pokemon or species name")
+	}
+
+	name := args[0]
+	if pokemon, caught := env.Config.Pokedex[name]; caught {
+		name = pokemon.SpeciesName()
+	}
+
+	species, err := env.Config.GetPokemonSpeciesContext(ctx, name)
+	if err != nil {
+		return err
+	}
+	return render(env, newSpeciesResult(species))
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+func TestCommandSpecies(t *testing.T) {
+	t.Parallel()
+	stubs := map[string]string{
+		"/api/v2/pokemon-species/pikachu": `{
+			"id": 25,
+			"name": "pikachu",
+			"capture_rate": 190,
+			"is_legendary": false,
+			"growth_rate": {"name": "medium"},
+			"habitat": {"name": "forest"},
+			"generation": {"name": "generation-i"},
+			"genera": [{"genus": "Maus-Pokémon", "language": {"name": "de"}}, {"genus": "Mouse Pokémon", "language": {"name": "en"}}],
+			"flavor_text_entries": [
+				{"flavor_text": "When several of\nthese POKéMON gather", "language": {"name": "en"}},
+				{"flavor_text": "It stores electricity\fin its cheeks.", "language": {"name": "en"}},
+				{"flavor_text": "Il stocke", "language": {"name": "fr"}}
+			],
+			"evolution_chain": {"url": "https://pokeapi.co/api/v2/evolution-chain/10/"}
+		}`,
+	}
+	cases := []struct {
+		name     string
+		command  func(context.Context, *Env, []string) error
+		pokedex  map[string]pokeapi.Pokemon
+		args     []string
+		expected []string
+		stderr   string
+		wantErr  bool
+	}{
+		{
+			name:    "species of an uncaught pokemon",
+			command: commandSpecies,
+			args:    []string{"pikachu"},
+			expected: []string{
+				"Name: pikachu\nGenus: Mouse Pokémon\nGeneration: generation-i\nHabitat: forest\nCapture rate: 190/255\n",
+				// Latest English entry, on one line
+				"\nIt stores electricity in its cheeks.\n",
+			},
+		},
+		{
+			// Alternate forms look up the species saved with them
+			name:     "species of a caught form",
+			command:  commandSpecies,
+			pokedex:  map[string]pokeapi.Pokemon{"pikachu-rock-star": {Name: "pikachu-rock-star", Species: pokeapi.NamedAPIResource{Name: "pikachu"}}},
+			args:     []string{"pikachu-rock-star"},
+			expected: []string{"Name: pikachu\n"},
+		},
+		{
+			name:    "unknown species",
+			command: commandSpecies,
+			args:    []string{"missingno"},
+			wantErr: true,
+		},
+		{
+			name:     "inspect adds genus and flavor text",
+			command:  commandInspect,
+			pokedex:  map[string]pokeapi.Pokemon{"pikachu": {Name: "pikachu"}},
+			args:     []string{"pikachu"},
+			expected: []string{"Name: pikachu\nGenus: Mouse Pokémon\nHeight: 0\n", "\nIt stores electricity in its cheeks.\n"},
+		},
+		{
+			// Still shows the Pokedex entry if the species can't be fetched
+			name:     "inspect without species",
+			command:  commandInspect,
+			pokedex:  map[string]pokeapi.Pokemon{"eevee": {Name: "eevee"}},
+			args:     []string{"eevee"},
+			expected: []string{"Name: eevee\nHeight: 0\n"},
+			stderr:   "could not get species for eevee",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, stderr := newTestEnv(t, &pokeapi.Config{Client: stubAPIClient(stubs), Pokedex: c.pokedex})
+			err := c.command(context.Background(), env, c.args)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("Expected error %v, got: %v", c.wantErr, err)
+			}
+			for _, want := range c.expected {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%v", want, stdout.String())
+				}
+			}
+			if !strings.Contains(stderr.String(), c.stderr) {
+				t.Errorf("Expected stderr to contain %q, got %q", c.stderr, stderr.String())
+			}
+		})
+	}
+}
@@ -1,46 +1,28 @@
 package command
 
 import (
-	"bytes"
-	"io"
-	"os"
-	"os/exec"
+	"context"
+	"errors"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/Fraegdegjevar/pokedexcli/internal/alias"
+	"github.com/Fraegdegjevar/pokedexcli/internal/history"
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 )
 
 func TestCommandHelp(t *testing.T) {
-	//Store os.Stdout so we can replace it after
-	// we switch it to a new buffer for this test,
-	// as otherwise subsequent tests (which run in a
-	//single shared process) could be affected by
-	// this mutation to global state.
-
-	// We need to set Stdout to an os.Pipe
-	// so we can inspect output.
-	r, w, _ := os.Pipe()
-	old := os.Stdout
-	os.Stdout = w
-	// very important global state restore for other tests
-	defer func() { os.Stdout = old }()
-
-	err := commandHelp(&pokeapi.Config{}, nil)
+	t.Parallel()
+	env, stdout, _ := newTestEnv(t, &pokeapi.Config{})
+
+	err := commandHelp(context.Background(), env, nil)
 	if err != nil {
 		t.Fatalf("Error with commandHelp: %v", err)
 	}
 
-	// Stop capturing
-	w.Close()
-
-	//Read what commandHelp wrote to Os.Stdout by copying
-	//into a buffer
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	r.Close()
-
-	output := buf.String()
+	output := stdout.String()
 	// Check string output has properly formatted welcome
 	// message:
 	if !strings.Contains(output,
@@ -56,36 +38,263 @@ func TestCommandHelp(t *testing.T) {
 	}
 }
 
-func TestCommandExit(t *testing.T) {
-	// This test function checks if os.Exit(0) is
-	// called by spawning a child process that
-	// calls this function.
+func TestCommandHelpCommand(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name     string
+		args     []string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			// Categories in order, commands sorted within them
+			name: "grouped by category",
+			args: nil,
+			expected: []string{
+				"Exploration:\n  explore: Display all pokemon in the area supplied\n  map: ",
+				"Collection:\n  catch: ",
+				"System:\n  alias: ", "  exit: Exit the Pokedex\n  help: ",
+			},
+		},
+		{
+			name: "single command",
+			args: []string{"explore"},
+			expected: []string{
+				"explore - Display all pokemon in the area supplied\n",
+				"Usage:\n  explore <location-area>\n",
+				"Arguments:\n  location-area  name of a location area",
+				"Examples:\n  explore pastoria-city-area\n",
+			},
+		},
+		{
+			name:     "built-in alias",
+			args:     []string{"i"},
+			expected: []string{"inspect - ", "Aliases: i\n"},
+		},
+		{
+			name:     "command without usage uses its name",
+			args:     []string{"map"},
+			expected: []string{"Usage:\n  map\n"},
+		},
+		{
+			name:    "unknown command",
+			args:    []string{"notacommand"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, _ := newTestEnv(t, &pokeapi.Config{})
+			err := commandHelp(context.Background(), env, c.args)
+			if c.wantErr {
+				if !errors.Is(err, ErrUnknownCommand) {
+					t.Fatalf("Expected ErrUnknownCommand, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error with commandHelp: %v", err)
+			}
+			for _, want := range c.expected {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%v", want, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+// Commands without a known category would be missing from the top-level help
+func TestCommandCategories(t *testing.T) {
+	t.Parallel()
+	for name, cmd := range GetSupportedCommands() {
+		if !slices.Contains(categories, cmd.Category) {
+			t.Errorf("Command %v has unknown category %q", name, cmd.Category)
+		}
+	}
+}
+
+func TestExecuteCommandAliases(t *testing.T) {
+	t.Parallel()
+	pokedex := map[string]pokeapi.Pokemon{"pikachu": {ID: 25, Name: "pikachu"}}
 
-	//Function will return if it determines it is in
-	// a child process spawned by this function
-	// (and not the parent process)
-	if os.Getenv("EXIT_TEST") == "1" {
-		commandExit(&pokeapi.Config{}, nil)
-		return
+	cases := []struct {
+		name     string
+		macros   map[string]string
+		input    []string
+		expected string
+		err      error
+	}{
+		{
+			name:     "built-in alias",
+			input:    []string{"ls"},
+			expected: "Your Pokedex:\n  - pikachu\n",
+		},
+		{
+			name:     "user alias gets the typed args",
+			macros:   map[string]string{"ip": "i"},
+			input:    []string{"ip", "pikachu"},
+			expected: "Name: pikachu\n",
+		},
+		{
+			name:     "macro runs each command",
+			macros:   map[string]string{"twice": "pokedex; pokedex"},
+			input:    []string{"twice"},
+			expected: "Your Pokedex:\n  - pikachu\nYour Pokedex:\n  - pikachu\n",
+		},
+		{
+			name:   "macros can't call themselves",
+			macros: map[string]string{"a": "pokedex; b", "b": "a"},
+			input:  []string{"a"},
+			err:    ErrAliasLoop,
+		},
+		{
+			// Load rejects these, but a Store can be built without it
+			name:   "empty macro",
+			macros: map[string]string{"x": ";"},
+			input:  []string{"x"},
+			err:    ErrEmptyAlias,
+		},
+		{
+			name:  "unknown command",
+			input: []string{"nope"},
+			err:   ErrUnknownCommand,
+		},
 	}
 
-	//Now launch a new instance of the current test
-	// i.e a child process
-	// os.Args[0] is the compiled test executable built by
-	// Go when testing with go test
-	cmd := exec.Command(os.Args[0], "-test.run=TestCommandExit")
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			env, stdout, _ := newTestEnv(t, &pokeapi.Config{Pokedex: pokedex})
+			env.Aliases = &alias.Store{Macros: c.macros}
 
-	//Mark as child process by setting the env (used above)
-	cmd.Env = append(os.Environ(), "EXIT_TEST=1")
+			err := ExecuteCommand(context.Background(), GetSupportedCommands(), c.input, env)
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("Expected error %v, got: %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(stdout.String(), c.expected) {
+				t.Errorf("Expected output starting %q, got %q", c.expected, stdout.String())
+			}
+		})
+	}
+}
 
-	// Run child and get result
-	err := cmd.Run()
+func TestCompletions(t *testing.T) {
+	t.Parallel()
+	env, _, _ := newTestEnv(t, &pokeapi.Config{Pokedex: map[string]pokeapi.Pokemon{
+		"pikachu": {Name: "pikachu"},
+		"pidgey":  {Name: "pidgey"},
+	}})
+	env.Aliases = &alias.Store{Macros: map[string]string{"explore-all": "map; map"}}
 
-	//Run() returns nil if command exits with status code 0,
-	// otherwise returns an error 'exit status n' for n != 0
-	// of type *exec.ExitError
-	if err != nil {
-		t.Fatalf("Expected commandExit to exit with status code 0, instead got error: %v", err)
+	cases := []struct {
+		line     string
+		expected []string
+	}{
+		{line: "ex", expected: []string{"exit", "explore", "explore-all"}},
+		{line: "l", expected: []string{"ls"}},
+		{line: "inspect pi", expected: []string{"pidgey", "pikachu"}},
+		// Built-in aliases complete the same as their command
+		{line: "i pik", expected: []string{"pikachu"}},
+		{line: "help ma", expected: []string{"map", "mapb", "matchup"}},
+		{line: "matchup pikachu ", expected: []string{"vs"}},
+		{line: "profile s", expected: []string{"switch"}},
+		// inspect only takes one argument
+		{line: "inspect pikachu p", expected: nil},
+		{line: "notacommand ", expected: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.line, func(t *testing.T) {
+			t.Parallel()
+			got := Completions(env, GetSupportedCommands(), c.line)
+			if !slices.Equal(got, c.expected) {
+				t.Errorf("Expected %v, got %v", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestCommandHistory(t *testing.T) {
+	t.Parallel()
+	hist := &history.History{}
+	for _, line := range []string{"map", "catch pikachu", "inspect pikachu"} {
+		hist.Add(line)
+	}
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: nil, expected: "    1  map\n    2  catch pikachu\n    3  inspect pikachu\n"},
+		{args: []string{"1"}, expected: "    3  inspect pikachu\n"},
+		{args: []string{"search", "pikachu"}, expected: "    2  catch pikachu\n    3  inspect pikachu\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(strings.Join(c.args, " "), func(t *testing.T) {
+			t.Parallel()
+			env, stdout, _ := newTestEnv(t, &pokeapi.Config{})
+			env.History = hist
+			err := commandHistory(context.Background(), env, c.args)
+			if err != nil {
+				t.Fatalf("Error with commandHistory: %v", err)
+			}
+			if stdout.String() != c.expected {
+				t.Errorf("Expected %q, got %q", c.expected, stdout.String())
+			}
+		})
+	}
+}
+
+func TestCommandExit(t *testing.T) {
+	// exit no longer calls os.Exit - it asks the caller to shut down
+	// by returning ErrExit, wrapped by ExecuteCommand.
+	t.Parallel()
+	env, _, _ := newTestEnv(t, &pokeapi.Config{})
+	err := ExecuteCommand(context.Background(), GetSupportedCommands(), []string{"exit"}, env)
+	if !errors.Is(err, ErrExit) {
+		t.Fatalf("Expected exit to return ErrExit, instead got: %v", err)
+	}
+}
+
+func TestCommandPokedexOutput(t *testing.T) {
+	t.Parallel()
+	pokedex := map[string]pokeapi.Pokemon{
+		"pikachu":   {ID: 25, Name: "pikachu"},
+		"bulbasaur": {ID: 1, Name: "bulbasaur"},
+	}
+
+	cases := []struct {
+		format   output.Format
+		expected string
+	}{
+		{format: output.Text, expected: "Your Pokedex:\n  - bulbasaur\n  - pikachu\n"},
+		{format: output.Table, expected: "NAME       ID  TYPES\nbulbasaur  1   \npikachu    25  \n"},
+		{format: output.YAML, expected: "---\npokemon:\n  - name: bulbasaur\n    id: 1\n    types: []\n  - name: pikachu\n    id: 25\n    types: []\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.format), func(t *testing.T) {
+			t.Parallel()
+			env, stdout, _ := newTestEnv(t, &pokeapi.Config{Pokedex: pokedex, Output: c.format})
+
+			err := commandPokedex(context.Background(), env, nil)
+			if err != nil {
+				t.Fatalf("Error with commandPokedex: %v", err)
+			}
+			if stdout.String() != c.expected {
+				t.Errorf("Expected:\n%q\ngot:\n%q", c.expected, stdout.String())
+			}
+		})
 	}
 }
 
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+)
+
+// Tab completion for the REPL's line editor. Completions works out which
+// word is being typed and asks the command (see cliCommand.Complete) what
+// could go there.
+
+// Candidates for the word being typed at the end of line, sorted. line is
+// everything before the cursor.
+func Completions(env *Env, supportedCommands map[string]cliCommand, line string) []string {
+	words := strings.Fields(strings.ToLower(line))
+	// A trailing space means we're starting a new word
+	current := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var candidates []string
+	if len(words) == 0 {
+		candidates = completeCommandNames(env)
+	} else if cmd, exists := lookupCommand(supportedCommands, words[0]); exists && cmd.Complete != nil {
+		candidates = cmd.Complete(env, words[1:])
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) {
+			matches = append(matches, c)
+		}
+	}
+	slices.Sort(matches)
+	return slices.Compact(matches)
+}
+
+// Only complete the first argument, for commands that take one
+func firstArg(complete func(env *Env) []string) func(*Env, []string) []string {
+	return func(env *Env, args []string) []string {
+		if len(args) > 0 {
+			return nil
+		}
+		return complete(env)
+	}
+}
+
+// Commands, built-in aliases and the user's aliases
+func completeCommandNames(env *Env) []string {
+	var names []string
+	for name, cmd := range GetSupportedCommands() {
+		names = append(names, name)
+		names = append(names, cmd.Aliases...)
+	}
+	return append(names, env.Aliases.Names()...)
+}
+
+func completeLocationAreas(env *Env) []string {
+	return env.Config.SeenLocationAreas()
+}
+
+func completeKnownPokemon(env *Env) []string {
+	return env.Config.KnownPokemon()
+}
+
+func completeCaughtPokemon(env *Env) []string {
+	names := make([]string, 0, len(env.Config.Pokedex))
+	for name := range env.Config.Pokedex {
+		names = append(names, name)
+	}
+	return names
+}
+
+func completeProfile(env *Env, args []string) []string {
+	switch {
+	case len(args) == 0:
+		return []string{"list", "create", "switch", "delete"}
+	case len(args) == 1 && (args[0] == "switch" || args[0] == "delete"):
+		if env.Config.Profiles == nil {
+			return nil
+		}
+		names, _ := env.Config.Profiles.List()
+		return names
+	}
+	return nil
+}
+
+func completeAlias(env *Env, args []string) []string {
+	switch {
+	case len(args) == 0:
+		return []string{"list", "set", "delete"}
+	case len(args) == 1 && args[0] == "delete":
+		return env.Aliases.Names()
+	}
+	return nil
+}
+
+func completeMatchup(env *Env, args []string) []string {
+	switch len(args) {
+	case 0, 2:
+		return append(env.Config.KnownPokemon(), pokeapi.BattleTypes...)
+	case 1:
+		return []string{"vs"}
+	}
+	return nil
+}
+
+func completeMoves(env *Env, args []string) []string {
+	if len(args) == 0 {
+		return env.Config.KnownPokemon()
+	}
+	switch args[len(args)-1] {
+	case "--method":
+		return moveLearnMethods
+	case "--sort":
+		return moveSortKeys
+	case "--version":
+		return nil
+	}
+	return []string{"--method", "--version", "--sort"}
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// A Client answering API requests from stubs, canned responses keyed by
+// path. Anything else is a 404, apart from battle types the stubs don't set
+// up - they take and deal normal damage, so commands that need every type
+// (e.g coverage) still work.
+func stubAPIClient(stubs map[string]string) *pokeapi.Client {
+	return &pokeapi.Client{HTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		body, ok := stubs[r.URL.Path]
+		if !ok {
+			name, isType := strings.CutPrefix(r.URL.Path, "/api/v2/type/")
+			if isType && slices.Contains(pokeapi.BattleTypes, name) {
+				body, ok = fmt.Sprintf(`{"name": %q}`, name), true
+			}
+		}
+		if !ok {
+			rec.WriteHeader(http.StatusNotFound)
+		}
+		rec.WriteString(body)
+		return rec.Result(), nil
+	})}}
+}
+
+// An Env that writes to buffers rather than the terminal, so tests can check
+// output and run in parallel. Cache messages go to the stderr buffer. If
+// conf has no Cache it gets its own, closed when the test ends. If it has no
+// Client it gets one with no stubs, so only battle types can be fetched.
+func newTestEnv(t *testing.T, conf *pokeapi.Config) (env *Env, stdout, stderr *bytes.Buffer) {
+	t.Helper()
+	if conf.Cache == nil {
+		conf.Cache = pokecache.NewCache(time.Hour)
+		// Stop its reap goroutine
+		t.Cleanup(func() { conf.Cache.Close() })
+	}
+	if conf.Client == nil {
+		conf.Client = stubAPIClient(nil)
+	}
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	if conf.Log == nil {
+		conf.Log = stderr
+	}
+	env = &Env{
+		Stdout: stdout,
+		Stderr: stderr,
+		Stdin:  strings.NewReader(""),
+		Config: conf,
+	}
+	return env, stdout, stderr
+}
@@ -1,69 +1,308 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/Fraegdegjevar/pokedexcli/internal/alias"
+	"github.com/Fraegdegjevar/pokedexcli/internal/history"
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 )
 
-// Registry of CLI commands
+// Registry of CLI commands. Usage, Args, Examples and Aliases are shown by
+// help <command>, Category groups the command in the top-level help.
+// Complete, if set, gives the candidates for tab completing the argument
+// after args - see completion.go.
 type cliCommand struct {
 	Name        string
 	Description string
-	Callback    func(*pokeapi.Config, []string) error
+	Callback    func(context.Context, *Env, []string) error
+	Complete    func(env *Env, args []string) []string
+
+	Category Category
+	// Synopsis, e.g "explore <location-area>". Defaults to the name.
+	Usage    string
+	Args     []cliArg
+	Examples []string
+	Aliases  []string
+}
+
+type cliArg struct {
+	Name        string
+	Description string
+}
+
+// Category groups commands in the top-level help
+type Category string
+
+const (
+	CategoryExploration Category = "exploration"
+	CategoryCollection  Category = "collection"
+	CategorySystem      Category = "system"
+)
+
+// The order categories are listed in by help
+var categories = []Category{CategoryExploration, CategoryCollection, CategorySystem}
+
+// Env is the execution context for a command - where it reads input from and
+// writes output to, plus the Config it works on. Commands never touch
+// os.Stdout/os.Stderr directly, so they can be run from anything (the REPL,
+// a script, a test) and have their output redirected. Point Config.Log at
+// Stderr to have the API layer's messages go there too.
+type Env struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+	Config *pokeapi.Config
+	// The user's aliases and macros. nil means there aren't any.
+	Aliases *alias.Store
+	// Lines entered at the REPL prompt. nil outside the REPL.
+	History *history.History
+}
+
+// An Env using the process's stdin, stdout and stderr
+func NewEnv(config *pokeapi.Config) *Env {
+	return &Env{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+		Config: config,
+	}
 }
 
 // Define our supportedCommands and register
 // cliCommands
 func GetSupportedCommands() map[string]cliCommand {
 	supportedCommands := map[string]cliCommand{
+		"alias": {
+			Name:        "alias",
+			Description: "List, set or delete your own aliases and macros",
+			Callback:    commandAlias,
+			Category:    CategorySystem,
+			Usage:       "alias [list] | set <name> <command>[; <command>...] | delete <name>",
+			Args: []cliArg{
+				{Name: "name", Description: "what you'll type: lower case letters, digits, - and _"},
+				{Name: "command", Description: "what it runs - separate commands with ; for a macro. Anything typed after the alias is added to the last command"},
+			},
+			Examples: []string{"alias set cp catch pikachu", "alias set tour map; explore pastoria-city-area", "alias delete cp"},
+			Complete: completeAlias,
+		},
 		"catch": {
 			Name:        "catch",
 			Description: "attempt to catch a pokemon",
 			Callback:    commandCatch,
+			Category:    CategoryCollection,
+			Usage:       "catch <pokemon>",
+			Aliases:     []string{"c"},
+			Args: []cliArg{
+				{Name: "pokemon", Description: "name of the pokemon to throw a Pokeball at"},
+			},
+			Examples: []string{"catch pikachu"},
+			Complete: firstArg(completeKnownPokemon),
+		},
+		"coverage": {
+			Name:        "coverage",
+			Description: "Find your team's shared weaknesses and gaps, and what to catch next from areas you've explored",
+			Callback:    commandCoverage,
+			Category:    CategoryCollection,
+			Usage:       "coverage [pokemon...]",
+			Args: []cliArg{
+				{Name: "pokemon", Description: "pokemon in your Pokedex to treat as the team - defaults to all of them"},
+			},
+			Examples: []string{"coverage", "coverage pikachu gyarados"},
+			Complete: func(env *Env, _ []string) []string { return completeCaughtPokemon(env) },
+		},
+		"evolutions": {
+			Name:        "evolutions",
+			Description: "Show how a pokemon evolves, marking the stages you've caught",
+			Callback:    commandEvolutions,
+			Category:    CategoryCollection,
+			Usage:       "evolutions <pokemon>",
+			Args: []cliArg{
+				{Name: "pokemon", Description: "any pokemon in the chain, caught or not"},
+			},
+			Examples: []string{"evolutions eevee", "evolutions pikachu"},
+			Aliases:  []string{"evo"},
+			Complete: firstArg(completeKnownPokemon),
 		},
 		"exit": {
 			Name:        "exit",
 			Description: "Exit the Pokedex",
 			Callback:    commandExit,
+			Category:    CategorySystem,
 		},
 		"explore": {
 			Name:        "explore",
 			Description: "Display all pokemon in the area supplied",
 			Callback:    commandExplore,
+			Category:    CategoryExploration,
+			Usage:       "explore <location-area>",
+			Aliases:     []string{"e"},
+			Args: []cliArg{
+				{Name: "location-area", Description: "name of a location area, as listed by map"},
+			},
+			Examples: []string{"explore pastoria-city-area"},
+			Complete: firstArg(completeLocationAreas),
 		},
 		"help": {
 			Name:        "help",
 			Description: "Displays a help message",
 			Callback:    commandHelp,
+			Category:    CategorySystem,
+			Usage:       "help [command]",
+			Args: []cliArg{
+				{Name: "command", Description: "show usage, arguments and examples for this command"},
+			},
+			Examples: []string{"help", "help explore"},
+			Complete: firstArg(completeCommandNames),
+		},
+		"history": {
+			Name:        "history",
+			Description: "List or search the commands you've entered",
+			Callback:    commandHistory,
+			Category:    CategorySystem,
+			Usage:       "history [count] | search <text>",
+			Args: []cliArg{
+				{Name: "count", Description: "only show this many of the most recent commands"},
+				{Name: "text", Description: "show commands containing this"},
+			},
+			Examples: []string{"history", "history 10", "history search pikachu", "!12", "!catch", "!?pika", "!!"},
+			Complete: firstArg(func(*Env) []string { return []string{"search"} }),
 		},
 		"inspect": {
 			Name:        "inspect",
 			Description: "Inspect a caught pokemon's pokedex entry",
 			Callback:    commandInspect,
+			Category:    CategoryCollection,
+			Usage:       "inspect <pokemon>",
+			Aliases:     []string{"i"},
+			Args: []cliArg{
+				{Name: "pokemon", Description: "name of a pokemon in your Pokedex"},
+			},
+			Examples: []string{"inspect pikachu"},
+			Complete: firstArg(completeCaughtPokemon),
 		},
 		"map": {
 			Name:        "map",
 			Description: "Displays the names of the next 20 location areas in the Pokemon world.",
 			// Closure to allow us to return a function of more than just *pokeapi.Config
 			Callback: commandMap,
+			Category: CategoryExploration,
 		},
 		"mapb": {
 			Name:        "mapb",
 			Description: "Displays the names of the previous 20 location areas in the Pokemon world.",
 			Callback:    commandMapb,
+			Category:    CategoryExploration,
+		},
+		"matchup": {
+			Name:        "matchup",
+			Description: "Show a pokemon or type's weaknesses, resistances and immunities, or how two match up",
+			Callback:    commandMatchup,
+			Category:    CategoryCollection,
+			Usage:       "matchup <pokemon|type> [vs <pokemon|type>]",
+			Args: []cliArg{
+				{Name: "pokemon|type", Description: "any pokemon, caught or not, or a type such as fire"},
+			},
+			Examples: []string{"matchup gyarados", "matchup dragon", "matchup pikachu vs gyarados"},
+			Complete: completeMatchup,
+		},
+		"moves": {
+			Name:        "moves",
+			Description: "Show the moves a pokemon can learn, with their power, accuracy and PP",
+			Callback:    commandMoves,
+			Category:    CategoryCollection,
+			Usage:       "moves <pokemon> [--method level-up|machine|egg|tutor] [--version <version-group>] [--sort level|name|power|accuracy|pp|type|class]",
+			Args: []cliArg{
+				{Name: "pokemon", Description: "any pokemon, caught or not"},
+				{Name: "--method", Description: "only moves learnt this way"},
+				{Name: "--version", Description: "version group to show, e.g red-blue - defaults to the latest"},
+				{Name: "--sort", Description: "order of the list - defaults to level. power, accuracy and pp go highest first"},
+			},
+			Examples: []string{"moves pikachu", "moves pikachu --method level-up --version red-blue", "moves gyarados --method machine --sort power"},
+			Complete: completeMoves,
 		},
 		"pokedex": {
 			Name:        "pokedex",
 			Description: "Displays the names of all pokemon in your pokedex.",
 			Callback:    commandPokedex,
+			Category:    CategoryCollection,
+			Aliases:     []string{"ls"},
+		},
+		"species": {
+			Name:        "species",
+			Description: "Show Pokedex text, genus, capture rate and more for a pokemon species",
+			Callback:    commandSpecies,
+			Category:    CategoryCollection,
+			Usage:       "species <pokemon>",
+			Args: []cliArg{
+				{Name: "pokemon", Description: "name of any pokemon, caught or not"},
+			},
+			Examples: []string{"species pikachu", "species mewtwo"},
+			Complete: firstArg(completeKnownPokemon),
+		},
+		"profile": {
+			Name:        "profile",
+			Description: "List, create, switch or delete trainer profiles",
+			Callback:    commandProfile,
+			Category:    CategorySystem,
+			Usage:       "profile [list] | create <name> | switch <name> | delete <name>",
+			Args: []cliArg{
+				{Name: "name", Description: "profile name: lower case letters, digits, - and _"},
+			},
+			Examples: []string{"profile", "profile create kanto", "profile switch kanto", "profile delete kanto"},
+			Complete: completeProfile,
 		},
 	}
 	return supportedCommands
 }
 
+// Returned (wrapped) by ExecuteCommand when the command doesn't exist
+var ErrUnknownCommand = errors.New("unknown command")
+
+// Returned (wrapped) by ExecuteCommand when a user macro ends up running itself
+var ErrAliasLoop = errors.New("alias loop")
+
+// Returned (wrapped) by ExecuteCommand when a user macro has nothing to run
+var ErrEmptyAlias = errors.New("alias has no commands")
+
+// Backstop on how deep macros can call other macros, on top of the loop check
+const maxMacroDepth = 10
+
+// Find a command by its name or one of its built-in aliases
+func lookupCommand(supportedCommands map[string]cliCommand, name string) (cliCommand, bool) {
+	cmd, exists := supportedCommands[name]
+	if exists {
+		return cmd, true
+	}
+	for _, cmd := range supportedCommands {
+		if slices.Contains(cmd.Aliases, name) {
+			return cmd, true
+		}
+	}
+	return cliCommand{}, false
+}
+
 // Match input (first word) to supported commands and callback.
-func ExecuteCommand(supportedCommands map[string]cliCommand, input []string, config *pokeapi.Config) error {
+// ctx is passed through to the command so it can be cancelled, e.g by Ctrl-C.
+// Commands and built-in aliases win over the user's aliases, which are
+// expanded and run one command at a time - see internal/alias.
+func ExecuteCommand(ctx context.Context, supportedCommands map[string]cliCommand, input []string, env *Env) error {
+	return execute(ctx, supportedCommands, input, env, nil)
+}
+
+// macros is the chain of user macros we are expanding, to catch loops
+func execute(ctx context.Context, supportedCommands map[string]cliCommand, input []string, env *Env, macros []string) error {
+	// Nothing to run
+	if len(input) == 0 {
+		return nil
+	}
 	//Match command entered to cliCommand struct and handle
 	// noexist
 	cmdName := input[0]
@@ -71,18 +310,53 @@ func ExecuteCommand(supportedCommands map[string]cliCommand, input []string, con
 	//all words after the first 'command word' are treated as args.
 	//Individual command* functions handle the args slice individually.
 	args := input[1:]
-	cmd, exists := GetSupportedCommands()[cmdName]
+	cmd, exists := lookupCommand(supportedCommands, cmdName)
 
 	if exists {
-		//call function in callback - passing config pointer
-		// note we update the values in config inside the called function
-		// via config pointer.
-		err := cmd.Callback(config, args)
+		//call function in callback - passing the Env, whose config pointer
+		// lets the called function update the values in config.
+		err := cmd.Callback(ctx, env, args)
 		if err != nil {
-			return fmt.Errorf("error calling %s: %v", cmd.Name, err)
+			return fmt.Errorf("error calling %s: %w", cmd.Name, err)
+		}
+		return nil
+	}
+
+	expansion, isAlias := env.Aliases.Get(cmdName)
+	if !isAlias {
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, cmdName)
+	}
+	if slices.Contains(macros, cmdName) || len(macros) >= maxMacroDepth {
+		chain := append(slices.Clone(macros), cmdName)
+		return fmt.Errorf("%w: %s", ErrAliasLoop, strings.Join(chain, " -> "))
+	}
+	macros = append(slices.Clone(macros), cmdName)
+
+	// Anything typed after the alias goes on the end of its last command,
+	// like a shell alias
+	commands := alias.Commands(expansion)
+	if len(commands) == 0 {
+		return fmt.Errorf("%w: %s", ErrEmptyAlias, cmdName)
+	}
+	last := len(commands) - 1
+	commands[last] = append(commands[last], args...)
+
+	for _, c := range commands {
+		// Stop between commands if cancelled, e.g Ctrl-C
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := execute(ctx, supportedCommands, c, env, macros)
+		if err != nil {
+			return fmt.Errorf("in alias %s: %w", cmdName, err)
 		}
-	} else {
-		fmt.Println("Unknown command")
 	}
 	return nil
 }
+
+// Print a command's result in the output format from the settings (--output).
+// Commands with results worth scripting against go through here rather than
+// printing as they go.
+func render(env *Env, r output.Result) error {
+	return output.Render(env.Stdout, env.Config.Output, r)
+}
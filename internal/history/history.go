@@ -0,0 +1,197 @@
+package history
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/atomicfile"
+)
+
+// Lines entered at the prompt, kept in a file (one line each, oldest first)
+// so they survive restarts. History satisfies the golang.org/x/term History
+// interface, so the line editor's up/down arrows walk through it.
+//
+// Lines are numbered from 1, oldest first, for the history command and
+// !-references - see Expand.
+
+// Default number of lines kept
+const DefaultMax = 1000
+
+type History struct {
+	// File lines are appended to. Empty keeps history in memory only.
+	Path string
+	// Oldest lines are dropped past this many. 0 means no limit.
+	Max int
+
+	// Oldest first
+	lines []string
+}
+
+// Read history from path. A missing file is not an error, there just isn't
+// any history yet. If the file has grown past max it is trimmed.
+func Load(path string, max int) (*History, error) {
+	h := &History{Path: path, Max: max}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read history file %v: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history file %v: %v", path, err)
+	}
+
+	if h.trim() {
+		// Rewrite the file so it doesn't keep growing. Best effort - the
+		// history in memory is fine either way.
+		data := strings.Join(h.lines, "\n") + "\n"
+		atomicfile.WriteFile(path, []byte(data), 0o600)
+	}
+	return h, nil
+}
+
+// Drop the oldest lines past Max. Returns true if any were dropped.
+func (h *History) trim() bool {
+	if h.Max <= 0 || len(h.lines) <= h.Max {
+		return false
+	}
+	h.lines = h.lines[len(h.lines)-h.Max:]
+	return true
+}
+
+// Record a line and append it to the file. Blank lines and repeats of the
+// previous line are skipped. Errors writing the file are ignored - losing
+// a line of history isn't worth interrupting anyone over.
+func (h *History) Add(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.ContainsAny(line, "\r\n") {
+		return
+	}
+	if n := len(h.lines); n > 0 && h.lines[n-1] == line {
+		return
+	}
+	h.lines = append(h.lines, line)
+	h.trim()
+
+	if h.Path == "" {
+		return
+	}
+	// 0600 - history can say more about someone than they might like
+	f, err := os.OpenFile(h.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// Number of lines in the history.
+func (h *History) Len() int {
+	return len(h.lines)
+}
+
+// The line idx back from the most recent, i.e At(0) is the last line added.
+// Panics if idx is out of range, as the term.History interface expects.
+func (h *History) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
+
+// Every line, oldest first.
+func (h *History) Lines() []string {
+	return append([]string(nil), h.lines...)
+}
+
+// A numbered line of history
+type Entry struct {
+	Index int    `json:"index"`
+	Line  string `json:"line"`
+}
+
+// Every line, numbered, oldest first.
+func (h *History) Entries() []Entry {
+	entries := make([]Entry, len(h.lines))
+	for i, line := range h.lines {
+		entries[i] = Entry{Index: i + 1, Line: line}
+	}
+	return entries
+}
+
+// Lines containing text (ignoring case), oldest first.
+func (h *History) Search(text string) []Entry {
+	text = strings.ToLower(text)
+	var found []Entry
+	for _, e := range h.Entries() {
+		if strings.Contains(strings.ToLower(e.Line), text) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Returned (wrapped) by Expand when a !-reference doesn't match anything
+var ErrNotFound = errors.New("not found in history")
+
+// Expand a !-reference to a previous line, like a shell:
+//
+//	!!       the last line
+//	!12      line 12
+//	!-2      the line before last
+//	!catch   the last line starting with catch
+//	!?pika   the last line containing pika
+//
+// Lines not starting with ! are returned as they are, with ok false.
+func (h *History) Expand(line string) (expanded string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "!") {
+		return line, false, nil
+	}
+	ref := line[1:]
+
+	notFound := fmt.Errorf("%w: %v", ErrNotFound, line)
+	switch {
+	case ref == "":
+		return "", true, fmt.Errorf("expected something after !, e.g !! or !12")
+	case ref == "!":
+		if len(h.lines) == 0 {
+			return "", true, notFound
+		}
+		return h.lines[len(h.lines)-1], true, nil
+	case strings.HasPrefix(ref, "?"):
+		found := h.Search(ref[1:])
+		if len(found) == 0 {
+			return "", true, notFound
+		}
+		return found[len(found)-1].Line, true, nil
+	}
+
+	if n, err := strconv.Atoi(ref); err == nil {
+		// Negative counts back from the end
+		if n < 0 {
+			n = len(h.lines) + 1 + n
+		}
+		if n < 1 || n > len(h.lines) {
+			return "", true, notFound
+		}
+		return h.lines[n-1], true, nil
+	}
+
+	for i := len(h.lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(h.lines[i], ref) {
+			return h.lines[i], true, nil
+		}
+	}
+	return "", true, notFound
+}
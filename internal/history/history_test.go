@@ -0,0 +1,90 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h, err := Load(path, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error loading missing file: %v", err)
+	}
+
+	for _, line := range []string{"map", "map", "  ", "explore pastoria-city-area", "catch pikachu", "inspect pikachu"} {
+		h.Add(line)
+	}
+
+	// Blank lines and repeats skipped, oldest dropped past Max
+	expected := []string{"explore pastoria-city-area", "catch pikachu", "inspect pikachu"}
+	if !reflect.DeepEqual(h.Lines(), expected) {
+		t.Errorf("Expected %v, got %v", expected, h.Lines())
+	}
+	if h.Len() != 3 || h.At(0) != "inspect pikachu" || h.At(2) != "explore pastoria-city-area" {
+		t.Errorf("Expected At(0) to be the most recent line, got %v", h.At(0))
+	}
+
+	// The file has every line added, and is trimmed to Max when loaded
+	loaded, err := Load(path, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error loading history: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Lines(), expected) {
+		t.Errorf("Expected %v loaded, got %v", expected, loaded.Lines())
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "explore pastoria-city-area\ncatch pikachu\ninspect pikachu\n" {
+		t.Errorf("Expected the file to be trimmed, got %q", data)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	h := &History{}
+	for _, line := range []string{"explore pastoria-city-area", "catch pikachu", "catch pidgey", "inspect pikachu"} {
+		h.Add(line)
+	}
+
+	cases := []struct {
+		input    string
+		expected string
+		ok       bool
+		notFound bool
+	}{
+		{input: "map", expected: "map"},
+		{input: "!!", expected: "inspect pikachu", ok: true},
+		{input: "!1", expected: "explore pastoria-city-area", ok: true},
+		{input: "!-2", expected: "catch pidgey", ok: true},
+		{input: "!catch", expected: "catch pidgey", ok: true},
+		{input: "!?pika", expected: "inspect pikachu", ok: true},
+		{input: "!?PIKA", expected: "inspect pikachu", ok: true},
+		{input: "!5", ok: true, notFound: true},
+		{input: "!0", ok: true, notFound: true},
+		{input: "!map", ok: true, notFound: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			got, ok, err := h.Expand(c.input)
+			if c.notFound {
+				if !errors.Is(err, ErrNotFound) {
+					t.Fatalf("Expected ErrNotFound, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != c.expected || ok != c.ok {
+				t.Errorf("Expected %q (%v), got %q (%v)", c.expected, c.ok, got, ok)
+			}
+		})
+	}
+
+	found := h.Search("catch")
+	if len(found) != 2 || found[0].Index != 2 || found[1].Line != "catch pidgey" {
+		t.Errorf("Expected the two catch lines, got %v", found)
+	}
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Commands render their results through here rather than printing as they go,
+// so the same result can be shown to a person or handed to a script.
+// A Result is encoded as-is for JSON/YAML, so give its fields json tags.
+
+// Format is how results are written out.
+type Format string
+
+const (
+	// The default - the human readable layout each command has always used
+	Text  Format = "text"
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+	Table Format = "table"
+)
+
+// Formats in the order we list them in help and errors
+var Formats = []Format{Text, JSON, YAML, Table}
+
+// Parse a format name, e.g from --output. Empty means Text.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return Text, nil
+	}
+	for _, f := range Formats {
+		if strings.EqualFold(name, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q: must be one of %v", name, Formats)
+}
+
+// A command's result.
+type Result interface {
+	// Write the human readable layout used by the Text format
+	WriteText(w io.Writer) error
+	// Column headings and rows for the Table format
+	Table() (header []string, rows [][]string)
+}
+
+// Write r to w in the given format. An empty format is Text.
+func Render(w io.Writer, f Format, r Result) error {
+	switch f {
+	case Text, "":
+		return r.WriteText(w)
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case YAML:
+		return writeYAML(w, r)
+	case Table:
+		header, rows := r.Table()
+		return writeTable(w, header, rows)
+	}
+	return fmt.Errorf("unknown output format %q", f)
+}
+
+// Columns are aligned with tabwriter. Headings are upper cased, like ps or kubectl.
+func writeTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(header) > 0 {
+		headings := make([]string, len(header))
+		for i, h := range header {
+			headings[i] = strings.ToUpper(h)
+		}
+		fmt.Fprintln(tw, strings.Join(headings, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,211 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+type testStat struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+type testResult struct {
+	Name  string     `json:"name"`
+	Types []string   `json:"types"`
+	Stats []testStat `json:"stats"`
+	Note  string     `json:"note"`
+	Empty []string   `json:"empty"`
+}
+
+func (r testResult) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Name: %v\n", r.Name)
+	return err
+}
+
+func (r testResult) Table() ([]string, [][]string) {
+	rows := [][]string{}
+	for _, s := range r.Stats {
+		rows = append(rows, []string{s.Name, fmt.Sprint(s.Value)})
+	}
+	return []string{"stat", "value"}, rows
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected Format
+		wantErr  bool
+	}{
+		{input: "", expected: Text},
+		{input: "json", expected: JSON},
+		{input: "YAML", expected: YAML},
+		{input: "table", expected: Table},
+		{input: "xml", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			f, err := ParseFormat(c.input)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("expected error %v, got %v", c.wantErr, err)
+			}
+			if f != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, f)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	result := testResult{
+		Name:  "pikachu",
+		Types: []string{"electric"},
+		Stats: []testStat{{Name: "hp", Value: 35}, {Name: "special-attack", Value: 50}},
+		Note:  "yes",
+		Empty: []string{},
+	}
+
+	cases := []struct {
+		format   Format
+		expected string
+	}{
+		{
+			format:   Text,
+			expected: "Name: pikachu\n",
+		},
+		{
+			format: JSON,
+			expected: `{
+  "name": "pikachu",
+  "types": [
+    "electric"
+  ],
+  "stats": [
+    {
+      "name": "hp",
+      "value": 35
+    },
+    {
+      "name": "special-attack",
+      "value": 50
+    }
+  ],
+  "note": "yes",
+  "empty": []
+}
+`,
+		},
+		{
+			// Fields keep their order and "yes" is quoted so it stays a string
+			format: YAML,
+			expected: `---
+name: pikachu
+types:
+  - electric
+stats:
+  - name: hp
+    value: 35
+  - name: special-attack
+    value: 50
+note: "yes"
+empty: []
+`,
+		},
+		{
+			format: Table,
+			expected: `STAT            VALUE
+hp              35
+special-attack  50
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Render(&buf, c.format, result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != c.expected {
+				t.Errorf("expected:\n%v\ngot:\n%v", c.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	cases := []any{
+		testResult{
+			Name:  "pikachu",
+			Types: []string{"electric"},
+			Stats: []testStat{{Name: "hp", Value: 35}},
+			Note:  "yes",
+			Empty: []string{},
+		},
+		map[string]any{
+			"quotes":  `say "hi"`,
+			"colon":   "a: b",
+			"hash":    "# not a comment",
+			"number":  "123",
+			"null":    "null",
+			"newline": "line one\nline two",
+			"empty":   "",
+			"nested":  map[string]any{"list": []any{1.5, true, nil, "-"}},
+			"unicode": "pokémon ♂",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeYAML(&buf, c)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Decoding the YAML should give back what decoding the JSON does
+			var got any
+			err = yaml.Unmarshal(buf.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("output isn't valid yaml: %v\n%v", err, buf.String())
+			}
+			data, err := json.Marshal(c)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var expected any
+			err = json.Unmarshal(data, &expected)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(normalise(got), expected) {
+				t.Errorf("expected %#v, got %#v\nfrom:\n%v", expected, got, buf.String())
+			}
+		})
+	}
+}
+
+// yaml decodes numbers as int or float64 where json always uses float64
+func normalise(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case map[string]any:
+		for k, val := range v {
+			v[k] = normalise(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = normalise(val)
+		}
+	}
+	return v
+}
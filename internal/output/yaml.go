@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Values are marshalled to JSON first, so json tags apply exactly as they do
+// for the JSON format. JSON is valid YAML, so decoding it into a yaml.Node
+// keeps the field order, then the node is re-encoded in block style.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding yaml: %v", err)
+	}
+	var doc yaml.Node
+	err = yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return fmt.Errorf("error encoding yaml: %v", err)
+	}
+	blockStyle(&doc)
+
+	// Start every document with --- so a run of commands is a valid stream
+	fmt.Fprintln(w, "---")
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err = enc.Encode(&doc)
+	if err != nil {
+		return fmt.Errorf("error encoding yaml: %v", err)
+	}
+	return enc.Close()
+}
+
+// Drop the flow style and quoting that came from the JSON, so the encoder
+// only quotes strings that would otherwise read as something else. It follows
+// YAML 1.2 though, so words older parsers take as bools (yes, off...) are
+// kept quoted. Empty lists and objects are still written [] and {}.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && yaml11Bools[strings.ToLower(n.Value)] {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+var yaml11Bools = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true,
+	"on": true, "off": true, "true": true, "false": true,
+}
@@ -0,0 +1,138 @@
+package pokeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+)
+
+// Default time to wait for a response, accounting for network latency on the
+// API side. Each retry waits this long again, see RetryPolicy.
+const DefaultTimeout = 2 * time.Second
+
+// HTTP client for the PokeAPI. One Client is owned by each Config and shared
+// by every request, so connections to the API are reused between calls.
+type Client struct {
+	HTTP  *http.Client
+	Retry RetryPolicy
+	// Shared by every request made with this Client. nil means no limit.
+	Limiter *RateLimiter
+	// If set, called with progress messages such as retries. Used for verbose output.
+	Logf func(format string, args ...any)
+
+	// Replaced in tests so retries don't actually wait
+	sleep func(time.Duration)
+}
+
+// Create a Client with its own transport and the given timeout per request.
+func NewClient(timeout time.Duration) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// We only ever talk to one host so keep a few connections around for it
+	transport.MaxIdleConnsPerHost = 10
+
+	return &Client{
+		HTTP: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+		Retry:   DefaultRetryPolicy,
+		Limiter: NewRateLimiter(DefaultRate, DefaultBurst),
+	}
+}
+
+// Used by a Config that doesn't have its own Client.
+var defaultClient = NewClient(DefaultTimeout)
+
+// What we get back from a GET. Validators are the ETag/Last-Modified headers
+// from the response. On a 304 NotModified is set and Body is empty.
+type apiResponse struct {
+	Body        []byte
+	Validators  pokecache.Validators
+	NotModified bool
+}
+
+// Fetch a resource and decode it into T, along with the response it came
+// from. This is the building block for every endpoint - e.g
+// fetch[Pokemon](ctx, client, u, pokecache.Validators{}). It doesn't touch
+// the cache, getCached wraps it with that. If v is not empty the API may
+// answer 304 Not Modified, in which case resp.NotModified is set and the
+// zero T is returned for the caller to decode what it already has.
+func fetch[T any](ctx context.Context, c *Client, u *url.URL, v pokecache.Validators) (resource T, resp apiResponse, err error) {
+	resp, err = c.get(ctx, u, v)
+	if err != nil || resp.NotModified {
+		return resource, resp, err
+	}
+	resource, err = decode[T](u, resp.Body)
+	return resource, resp, err
+}
+
+func decode[T any](u *url.URL, body []byte) (T, error) {
+	var resource T
+	err := json.Unmarshal(body, &resource)
+	if err != nil {
+		var zero T
+		return zero, &DecodeError{URL: u.String(), Err: err}
+	}
+	return resource, nil
+}
+
+// Perform a single GET, no retries. If v is not empty we send If-None-Match/If-Modified-Since
+// so the API can answer 304 Not Modified instead of the whole body.
+// retryAfter is the parsed Retry-After header of an error response, if any.
+func (c *Client) getOnce(ctx context.Context, u *url.URL, v pokecache.Validators) (resp apiResponse, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return apiResponse{}, 0, &RequestError{URL: u.String(), Err: err}
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	// Wait our turn - every attempt, including retries, counts against the limit
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return apiResponse{}, 0, err
+		}
+	}
+
+	//Do request - note that err only returns non nil
+	// if there was an error with the http exchange. So if we receive a response
+	// even if it is an error code, err is nil. We need to explicitly handle error codes.
+	httpResp, err := c.HTTP.Do(req)
+	if err != nil {
+		return apiResponse{}, 0, &RequestError{URL: u.String(), Err: err, Temporary: true}
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode == http.StatusNotModified {
+		return apiResponse{NotModified: true, Validators: v}, 0, nil
+	}
+
+	// Test for application errors i.e http error codes
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		retryAfter := parseRetryAfter(httpResp.Header.Get("Retry-After"), time.Now())
+		return apiResponse{}, retryAfter, &StatusError{URL: u.String(), StatusCode: httpResp.StatusCode}
+	}
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return apiResponse{}, 0, &RequestError{URL: u.String(), Err: fmt.Errorf("error reading response: %v", err), Temporary: true}
+	}
+
+	return apiResponse{
+		Body: body,
+		Validators: pokecache.Validators{
+			ETag:         httpResp.Header.Get("ETag"),
+			LastModified: httpResp.Header.Get("Last-Modified"),
+		},
+	}, 0, nil
+}
@@ -1,18 +1,29 @@
 package pokeapi
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/url"
+	"strings"
 
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+	"github.com/Fraegdegjevar/pokedexcli/internal/profile"
 )
 
 const (
-	baseURL              = "https://pokeapi.co/api/v2"
+	DefaultBaseURL       = "https://pokeapi.co/api/v2"
 	LocationAreaEndpoint = "/location-area/"
 	PokemonEndpoint      = "/pokemon/"
+	SpeciesEndpoint      = "/pokemon-species/"
+	TypeEndpoint         = "/type/"
+	MoveEndpoint         = "/move/"
+
+	// Path the API serves its resources under. Used to find the resource
+	// part of links the API hands back to us.
+	apiRoot = "/api/v2"
 )
 
 // command Config
@@ -22,128 +33,265 @@ type Config struct {
 	GetLocationAreasFunc func(*url.URL) (NamedAPIResourceList, error)
 	Cache                *pokecache.Cache
 	Pokedex              map[string]Pokemon
+	// HTTP client for the API. If nil a shared default client is used.
+	Client *Client
+	// Root of the API, e.g to use a self-hosted PokeAPI. If empty DefaultBaseURL is used.
+	BaseURL string
+	// Where the Pokedex is saved - see save.go. If empty the Pokedex isn't saved.
+	SaveFile string
+	// Trainer profiles, if enabled - see profile.go
+	Profiles *profile.Manager
+	// Called after switching profile, e.g to apply the profile's settings
+	OnProfileSwitch func(name string) error
+	// How commands print their results. Empty means output.Text.
+	Output output.Format
+	// Where cache hits and misses are reported, e.g the Env's stderr. nil
+	// means they aren't.
+	Log io.Writer
+	// Set by -verbose, for extra detail in Log
+	Verbose bool
+
+	// Coalesces concurrent requests for the same URL
+	flights flightGroup
+	// Names we've come across this session, for tab completion - see seen.go
+	seen seenNames
+}
+
+func (c *Config) logf(format string, args ...any) {
+	if c.Log != nil {
+		fmt.Fprintf(c.Log, format, args...)
+	}
+}
+
+func (c *Config) client() *Client {
+	if c.Client == nil {
+		return defaultClient
+	}
+	return c.Client
+}
+
+// Parse the configured base URL, falling back to DefaultBaseURL
+func (c *Config) baseURL() (*url.URL, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse base URL %v: %v", base, err)
+	}
+	return u, nil
+}
+
+// Build the URL for a resource under the base URL, e.g endpointURL(PokemonEndpoint, "pikachu")
+func (c *Config) endpointURL(elem ...string) (*url.URL, error) {
+	u, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	return u.JoinPath(elem...), nil
+}
+
+// Point a link returned by the API (e.g the next page) at our base URL.
+// The API builds these from its own idea of its host, which won't be our
+// mirror or test server. Empty links are left alone.
+func (c *Config) rebase(link string) (*url.URL, error) {
+	u, err := url.Parse(link)
+	if err != nil || link == "" {
+		return u, err
+	}
+
+	// Work out the resource path, i.e everything after the API root
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	resource := u.Path
+	if i := strings.Index(resource, apiRoot+"/"); i >= 0 {
+		resource = resource[i+len(apiRoot):]
+	} else if base.Path != "" && strings.HasPrefix(resource, base.Path+"/") {
+		resource = strings.TrimPrefix(resource, base.Path)
+	}
+
+	rebased := base.JoinPath(resource)
+	rebased.RawQuery = u.RawQuery
+	return rebased, nil
 }
 
 func (c *Config) UpdatePagination(resp *NamedAPIResourceList) error {
 	var err error
-	c.Next, err = url.Parse(resp.Next)
+	c.Next, err = c.rebase(resp.Next)
 	if err != nil {
-		fmt.Println("Error parsing response next URL field as a url.URL")
-		return err
+		return fmt.Errorf("error parsing next page URL %q: %v", resp.Next, err)
 	}
-	c.Previous, err = url.Parse(resp.Previous)
+	c.Previous, err = c.rebase(resp.Previous)
 	if err != nil {
-		fmt.Println("Error parsing response previous URL field as a url.URL")
-		return err
+		return fmt.Errorf("error parsing previous page URL %q: %v", resp.Previous, err)
 	}
 
 	return nil
 }
 
+// The Get*/CatchPokemon methods each have a ...Context variant taking a
+// context.Context, which cancels any request to the API when it is done
+// (e.g the user pressed Ctrl-C). The plain versions use context.Background().
+
 func (c *Config) GetLocationAreas(u *url.URL) (NamedAPIResourceList, error) {
-	var page NamedAPIResourceList
+	return c.GetLocationAreasContext(context.Background(), u)
+}
+
+func (c *Config) GetLocationAreasContext(ctx context.Context, u *url.URL) (NamedAPIResourceList, error) {
 	var err error
-	// Guard null url value
+	// Guard null url value - start from the first page
 	if u == nil {
-		u, err = url.Parse(baseURL + "/location-area/?offset=0&limit=20")
-	}
-
-	if err != nil {
-		return NamedAPIResourceList{}, fmt.Errorf("error parsing URL for GetLocationAreas: %v", err)
-	}
-
-	//Test if result in cache and parse if necessary
-	resp, exists := c.Cache.Get(u.String())
-	if exists {
-		fmt.Printf("Cache hit on url: %v\n", u)
-		err := json.Unmarshal(resp, &page)
+		u, err = c.endpointURL(LocationAreaEndpoint)
 		if err != nil {
-			return NamedAPIResourceList{}, err
+			return NamedAPIResourceList{}, fmt.Errorf("error building URL for GetLocationAreas: %v", err)
 		}
-		err = c.UpdatePagination(&page)
+		u.RawQuery = "offset=0&limit=20"
+	} else {
+		// Page links can come from a save file written with a different base
+		// URL, so point them at the current one when they're used
+		u, err = c.rebase(u.String())
 		if err != nil {
-			return NamedAPIResourceList{}, err
+			return NamedAPIResourceList{}, fmt.Errorf("error rebasing URL for GetLocationAreas: %v", err)
 		}
-		return page, nil
 	}
-	fmt.Printf("Cache miss on url: %v\n", u)
 
-	page, err = requestLocationAreas(u)
+	page, err := getCached[NamedAPIResourceList](ctx, c, u)
 	if err != nil {
 		return NamedAPIResourceList{}, err
 	}
 
-	resp, err = json.Marshal(&page)
+	err = c.UpdatePagination(&page)
 	if err != nil {
 		return NamedAPIResourceList{}, err
 	}
-
-	c.Cache.Add(u.String(), resp)
-	c.UpdatePagination(&page)
+	for _, area := range page.Results {
+		c.seen.addLocationArea(area.Name)
+	}
 
 	return page, nil
 }
 
-// Gets a specific location area resource. To add: cache checks and caching.
+// Gets a specific location area resource from the cache or API.
 func (c *Config) GetLocationArea(LocationAreaName string) (LocationArea, error) {
-	var locationArea LocationArea
+	return c.GetLocationAreaContext(context.Background(), LocationAreaName)
+}
 
-	// Handle empty strings
-	u, err := url.Parse(baseURL)
+func (c *Config) GetLocationAreaContext(ctx context.Context, LocationAreaName string) (LocationArea, error) {
+	// Append to url path as needed to hit correct resource
+	u, err := c.endpointURL(LocationAreaEndpoint, LocationAreaName)
 	if err != nil {
-		return LocationArea{}, fmt.Errorf("error parsing url in GetLocationArea: %v", err)
+		return LocationArea{}, fmt.Errorf("error building url in GetLocationArea: %v", err)
+	}
 
+	// No pagination update required.
+	area, err := getCached[LocationArea](ctx, c, u)
+	if err != nil {
+		return LocationArea{}, err
 	}
-	// Append to url path as needed to hit correct resource
-	u = u.JoinPath(LocationAreaEndpoint, LocationAreaName)
-	// Check for presence of URL as key in cache
-	resp, exists := c.Cache.Get(u.String())
-	if exists {
-		fmt.Printf("Cache hit on url: %v\n", u)
-		err = json.Unmarshal(resp, &locationArea)
-		if err != nil {
-			return LocationArea{}, nil
-		}
-		// No pagination update required.
-		return locationArea, nil
+	c.seen.addExplored(area)
+	return area, nil
+}
+
+// Get a pokemon from the cache, or the API if not cached.
+func (c *Config) GetPokemon(PokemonName string) (Pokemon, error) {
+	return c.GetPokemonContext(context.Background(), PokemonName)
+}
+
+func (c *Config) GetPokemonContext(ctx context.Context, PokemonName string) (Pokemon, error) {
+	u, err := c.endpointURL(PokemonEndpoint, PokemonName)
+	if err != nil {
+		return Pokemon{}, err
 	}
 
-	// If not in cache, call API
-	fmt.Printf("Cache miss on url: %v\n", u)
-	locationArea, err = requestLocationArea(u)
+	pokemon, err := getCached[Pokemon](ctx, c, u)
 	if err != nil {
-		return LocationArea{}, nil
+		return Pokemon{}, err
 	}
+	c.seen.addPokemon(pokemon.Name)
+	return pokemon, nil
+}
+
+// Shared cache logic for every resource, keyed on the URL:
+//   - fresh entry in the cache: decode and return it
+//   - expired entry with an ETag/Last-Modified: ask the API if it changed, and
+//     on a 304 reuse the bytes we have and mark them fresh again
+//   - otherwise fetch and cache the body along with its validators
+//
+// Concurrent callers for the same URL share one request. Cache messages go to
+// c.Log, not stdout, so they don't get mixed up with json/yaml output.
+// Generic function rather than method as Go methods can't have type parameters.
+func getCached[T any](ctx context.Context, c *Config, u *url.URL) (T, error) {
+	key := u.String()
 
-	//Add to cache - marshal
-	resp, err = json.Marshal(locationArea)
+	resp, exists := c.Cache.Get(key)
+	if exists {
+		c.logf("Cache hit on url: %v\n", u)
+		return decode[T](u, resp)
+	}
+	c.logf("Cache miss on url: %v\n", u)
+
+	val, _, err := c.flights.Do(ctx, key, func() (any, error) {
+		stale, validators, hasStale := c.Cache.GetStale(key)
+
+		resource, apiResp, err := fetch[T](ctx, c.client(), u, validators)
+		if err != nil {
+			return nil, err
+		}
+
+		if !apiResp.NotModified {
+			c.Cache.AddWithValidators(key, apiResp.Body, apiResp.Validators)
+			return resource, nil
+		}
+
+		if !hasStale {
+			return nil, fmt.Errorf("API returned 304 Not Modified but nothing is cached for %v", u)
+		}
+		c.logf("Revalidated cached url: %v\n", u)
+		resource, err = decode[T](u, stale)
+		if err != nil {
+			return nil, err
+		}
+		c.Cache.Refresh(key)
+		return resource, nil
+	})
 	if err != nil {
-		return LocationArea{}, nil
+		var zero T
+		return zero, err
 	}
-	c.Cache.Add(u.String(), resp)
 
-	return locationArea, nil
+	return val.(T), nil
 }
 
-// Get pokemon from API or cache
-func (c *Config) CatchPokemon(PokemonName string) error {
-	// construct url
-	u, err := url.Parse(baseURL)
-	if err != nil {
-		return fmt.Errorf("could not parse baseURL: %v", err)
+// Release anything the Config holds on to - saves the Pokedex and stops the
+// cache's reap goroutine. Call before exiting.
+func (c *Config) Close() error {
+	err := c.SavePokedex()
+	if c.Cache != nil {
+		c.Cache.Close()
 	}
-	u = u.JoinPath(PokemonEndpoint, PokemonName)
+	return err
+}
+
+// Get pokemon from API or cache and try to catch it, printing how it went to w
+func (c *Config) CatchPokemon(w io.Writer, PokemonName string) error {
+	return c.CatchPokemonContext(context.Background(), w, PokemonName)
+}
+
+func (c *Config) CatchPokemonContext(ctx context.Context, w io.Writer, PokemonName string) error {
 	// Request pokemon
-	pokemon, err := RequestPokemon(u)
+	pokemon, err := c.GetPokemonContext(ctx, PokemonName)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Throwing a Pokeball at %s...\n", pokemon.Name)
+	fmt.Fprintf(w, "Throwing a Pokeball at %s...\n", pokemon.Name)
 
 	//Check if pokemon already caught! We still catch but this is helpful.
 	_, caught := c.Pokedex[pokemon.Name]
-	fmt.Printf("%v already caught: %v\n", pokemon.Name, caught)
+	fmt.Fprintf(w, "%v already caught: %v\n", pokemon.Name, caught)
 
 	// Determine probability of catching pokemon: 100 - base_exp/5 %
 	// We roll a 100 sided die. If the roll is < prob, success, else fail.
@@ -153,13 +301,13 @@ func (c *Config) CatchPokemon(PokemonName string) error {
 	roll := rand.Intn(100)
 
 	if roll < prob {
-		fmt.Printf("%v was caught!\n", pokemon.Name)
-		// add pokemon to pokemon map here...
+		fmt.Fprintf(w, "%v was caught!\n", pokemon.Name)
+		// add pokemon to pokemon map here and save straight away
 		c.Pokedex[pokemon.Name] = pokemon
-		return nil
+		return c.SavePokedex()
 	}
 	// If pokemon escaped...
-	fmt.Printf("%s escaped!\n", pokemon.Name)
+	fmt.Fprintf(w, "%s escaped!\n", pokemon.Name)
 	return nil
 }
 
@@ -0,0 +1,55 @@
+package pokeapi
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Errors returned by Client. Callers can use errors.As to tell them apart,
+// e.g to report a 404 as "no such pokemon" rather than a generic failure.
+
+// The request could not be made or no response was received (bad URL,
+// network error, timeout...). Temporary is set for failures that might go
+// away if we try again, i.e not a request we couldn't even build.
+type RequestError struct {
+	URL       string
+	Err       error
+	Temporary bool
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("error performing request to %v: %v", e.URL, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// The API responded with a non 2xx status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status from %v: %v %v", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// True if the API said the resource doesn't exist.
+func (e *StatusError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// The response body wasn't the JSON we expected.
+type DecodeError struct {
+	URL string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("error decoding response from %v: %v", e.URL, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
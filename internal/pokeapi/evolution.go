@@ -0,0 +1,138 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Get the evolution chain a pokemon species belongs to. The API only links
+// to chains from species, so this fetches the species first (both cached).
+func (c *Config) GetEvolutionChain(speciesName string) (EvolutionChain, error) {
+	return c.GetEvolutionChainContext(context.Background(), speciesName)
+}
+
+func (c *Config) GetEvolutionChainContext(ctx context.Context, speciesName string) (EvolutionChain, error) {
+	species, err := c.GetPokemonSpeciesContext(ctx, speciesName)
+	if err != nil {
+		return EvolutionChain{}, err
+	}
+	if species.Evolution_Chain.Url == "" {
+		return EvolutionChain{}, fmt.Errorf("%v has no evolution chain", species.Name)
+	}
+
+	// The link points at the API's own host, use ours instead
+	u, err := c.rebase(species.Evolution_Chain.Url)
+	if err != nil {
+		return EvolutionChain{}, fmt.Errorf("error parsing evolution chain URL for %v: %v", species.Name, err)
+	}
+	return getCached[EvolutionChain](ctx, c, u)
+}
+
+// How this stage is reached from the one before, e.g "level 16" or
+// "use thunder-stone". Stages with more than one way of evolving have them
+// joined with " or ". Empty for the first stage.
+func (l ChainLink) Conditions() string {
+	var ways []string
+	for _, d := range l.Evolution_Details {
+		if s := d.String(); s != "" && !slices.Contains(ways, s) {
+			ways = append(ways, s)
+		}
+	}
+	return strings.Join(ways, " or ")
+}
+
+// Describe the conditions, e.g "level 36", "trade holding metal-coat",
+// "level up with happiness 160, at night".
+func (d EvolutionDetail) String() string {
+	var parts []string
+	add := func(format string, args ...any) {
+		parts = append(parts, fmt.Sprintf(format, args...))
+	}
+
+	switch d.Trigger.Name {
+	case "level-up":
+		if d.Min_Level != nil {
+			add("level %d", *d.Min_Level)
+		} else {
+			add("level up")
+		}
+	case "use-item":
+		if d.Item != nil {
+			add("use %v", d.Item.Name)
+		} else {
+			add("use item")
+		}
+	case "trade":
+		add("trade")
+	case "":
+	default:
+		add("%v", d.Trigger.Name)
+	}
+
+	if d.Held_Item != nil {
+		add("holding %v", d.Held_Item.Name)
+	}
+	if d.Trade_Species != nil {
+		add("for %v", d.Trade_Species.Name)
+	}
+	if d.Known_Move != nil {
+		add("knowing %v", d.Known_Move.Name)
+	}
+	if d.Known_Move_Type != nil {
+		add("knowing a %v move", d.Known_Move_Type.Name)
+	}
+	if d.Min_Happiness != nil {
+		add("happiness %d", *d.Min_Happiness)
+	}
+	if d.Min_Affection != nil {
+		add("affection %d", *d.Min_Affection)
+	}
+	if d.Min_Beauty != nil {
+		add("beauty %d", *d.Min_Beauty)
+	}
+	if d.Location != nil {
+		add("at %v", d.Location.Name)
+	}
+	if d.Party_Species != nil {
+		add("with %v in the party", d.Party_Species.Name)
+	}
+	if d.Gender != nil {
+		// The API uses 1 for female and 2 for male
+		if *d.Gender == 1 {
+			add("female")
+		} else {
+			add("male")
+		}
+	}
+	if d.Relative_Physical_Stats != nil {
+		switch *d.Relative_Physical_Stats {
+		case 1:
+			add("attack > defense")
+		case -1:
+			add("attack < defense")
+		default:
+			add("attack = defense")
+		}
+	}
+	switch d.Time_Of_Day {
+	case "":
+	case "night":
+		add("at night")
+	default:
+		add("during the %v", d.Time_Of_Day)
+	}
+	if d.Needs_Overworld_Rain {
+		add("in the rain")
+	}
+	if d.Turn_Upside_Down {
+		add("console upside down")
+	}
+
+	// "level up" then the details read better as "level up with ..."
+	if len(parts) > 1 && parts[0] == "level up" {
+		return "level up with " + strings.Join(parts[1:], ", ")
+	}
+	return strings.Join(parts, ", ")
+}
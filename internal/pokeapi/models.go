@@ -46,12 +46,148 @@ type PokemonType struct {
 
 // Pokemon response when calling named endpoint with an ID - contains far more info than the
 // NamedAPIResource inside PokemonEncounter
+// Species is empty for pokemon saved before it was added - see SpeciesName.
+// Moves is never saved with the Pokedex - see SavePokedex.
 type Pokemon struct {
-	ID              int           `json:"id"`
-	Name            string        `json:"name"`
-	Height          int           `json:"height"`
-	Weight          int           `json:"weight"`
-	Stats           []PokemonStat `json:"stats"`
-	Types           []PokemonType `json:"types"`
-	Base_Experience int           `json:"base_experience"`
+	ID              int              `json:"id"`
+	Name            string           `json:"name"`
+	Height          int              `json:"height"`
+	Weight          int              `json:"weight"`
+	Stats           []PokemonStat    `json:"stats"`
+	Types           []PokemonType    `json:"types"`
+	Base_Experience int              `json:"base_experience"`
+	Species         NamedAPIResource `json:"species"`
+	Moves           []PokemonMove    `json:"moves"`
+}
+
+// A move the pokemon can learn, and how it learns it in each game
+type PokemonMove struct {
+	Move                  NamedAPIResource    `json:"move"`
+	Version_Group_Details []MoveVersionDetail `json:"version_group_details"`
+}
+
+// How a move is learnt in one version group, e.g level-up at level 26 in
+// red-blue. Level_Learned_At is 0 for anything but level-up.
+type MoveVersionDetail struct {
+	Level_Learned_At  int              `json:"level_learned_at"`
+	Move_Learn_Method NamedAPIResource `json:"move_learn_method"`
+	Version_Group     NamedAPIResource `json:"version_group"`
+}
+
+// APIResource - like NamedAPIResource but for resources that only have an ID,
+// such as evolution chains.
+type APIResource struct {
+	Url string `json:"url"`
+}
+
+// A name or description in a particular language, e.g "Mouse Pokémon" in "en"
+type Genus struct {
+	Genus    string           `json:"genus"`
+	Language NamedAPIResource `json:"language"`
+}
+
+// Pokedex entry text from one game
+type FlavorText struct {
+	Flavor_Text string           `json:"flavor_text"`
+	Language    NamedAPIResource `json:"language"`
+	Version     NamedAPIResource `json:"version"`
+}
+
+// Calling the pokemon-species endpoint returns what all forms of a pokemon
+// share - e.g deoxys-attack and deoxys-speed are both the deoxys species.
+// Habitat is nil for pokemon from later generations, which the API doesn't give one.
+type PokemonSpecies struct {
+	ID                  int               `json:"id"`
+	Name                string            `json:"name"`
+	Capture_Rate        int               `json:"capture_rate"`
+	Base_Happiness      int               `json:"base_happiness"`
+	Is_Baby             bool              `json:"is_baby"`
+	Is_Legendary        bool              `json:"is_legendary"`
+	Is_Mythical         bool              `json:"is_mythical"`
+	Growth_Rate         NamedAPIResource  `json:"growth_rate"`
+	Habitat             *NamedAPIResource `json:"habitat"`
+	Generation          NamedAPIResource  `json:"generation"`
+	Genera              []Genus           `json:"genera"`
+	Flavor_Text_Entries []FlavorText      `json:"flavor_text_entries"`
+	Evolution_Chain     APIResource       `json:"evolution_chain"`
+}
+
+// Calling the evolution-chain endpoint returns the whole family of a pokemon
+// as a tree, starting from its first (or baby) stage.
+type EvolutionChain struct {
+	ID    int       `json:"id"`
+	Chain ChainLink `json:"chain"`
+}
+
+// One stage in an evolution chain. Evolution_Details says how the previous
+// stage evolves into this one, and is empty for the first stage.
+type ChainLink struct {
+	Is_Baby           bool              `json:"is_baby"`
+	Species           NamedAPIResource  `json:"species"`
+	Evolution_Details []EvolutionDetail `json:"evolution_details"`
+	Evolves_To        []ChainLink       `json:"evolves_to"`
+}
+
+// The conditions for one way of evolving. Only the fields that apply are
+// set, the rest are null/zero - e.g a level up evolution just has Min_Level.
+type EvolutionDetail struct {
+	Trigger                 NamedAPIResource  `json:"trigger"`
+	Item                    *NamedAPIResource `json:"item"`
+	Held_Item               *NamedAPIResource `json:"held_item"`
+	Known_Move              *NamedAPIResource `json:"known_move"`
+	Known_Move_Type         *NamedAPIResource `json:"known_move_type"`
+	Location                *NamedAPIResource `json:"location"`
+	Party_Species           *NamedAPIResource `json:"party_species"`
+	Trade_Species           *NamedAPIResource `json:"trade_species"`
+	Min_Level               *int              `json:"min_level"`
+	Min_Happiness           *int              `json:"min_happiness"`
+	Min_Affection           *int              `json:"min_affection"`
+	Min_Beauty              *int              `json:"min_beauty"`
+	Gender                  *int              `json:"gender"`
+	Time_Of_Day             string            `json:"time_of_day"`
+	Needs_Overworld_Rain    bool              `json:"needs_overworld_rain"`
+	Turn_Upside_Down        bool              `json:"turn_upside_down"`
+	Relative_Physical_Stats *int              `json:"relative_physical_stats"`
+}
+
+// Calling the type endpoint with a name returns how the type fares against
+// the others - see types.go for working out multipliers from it.
+type Type struct {
+	ID               int           `json:"id"`
+	Name             string        `json:"name"`
+	Damage_Relations TypeRelations `json:"damage_relations"`
+}
+
+// The _To lists are this type attacking, the _From lists are this type
+// defending. Types in none of the lists do normal (x1) damage.
+type TypeRelations struct {
+	No_Damage_To       []NamedAPIResource `json:"no_damage_to"`
+	Half_Damage_To     []NamedAPIResource `json:"half_damage_to"`
+	Double_Damage_To   []NamedAPIResource `json:"double_damage_to"`
+	No_Damage_From     []NamedAPIResource `json:"no_damage_from"`
+	Half_Damage_From   []NamedAPIResource `json:"half_damage_from"`
+	Double_Damage_From []NamedAPIResource `json:"double_damage_from"`
+}
+
+// Calling the move endpoint with a name returns the move's details.
+// Power, Accuracy and PP are null for moves they don't apply to, e.g status
+// moves have no power and some never miss.
+type Move struct {
+	ID             int              `json:"id"`
+	Name           string           `json:"name"`
+	Power          *int             `json:"power"`
+	Accuracy       *int             `json:"accuracy"`
+	PP             *int             `json:"pp"`
+	Priority       int              `json:"priority"`
+	Effect_Chance  *int             `json:"effect_chance"`
+	Damage_Class   NamedAPIResource `json:"damage_class"`
+	Type           NamedAPIResource `json:"type"`
+	Effect_Entries []VerboseEffect  `json:"effect_entries"`
+}
+
+// What a move does, in full and in short, in one language
+type VerboseEffect struct {
+	Effect       string           `json:"effect"`
+	Short_Effect string           `json:"short_effect"`
+	Language     NamedAPIResource `json:"language"`
 }
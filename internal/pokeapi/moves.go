@@ -0,0 +1,66 @@
+package pokeapi
+
+import (
+	"cmp"
+	"context"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Get a move from the cache, or the API if not cached.
+func (c *Config) GetMove(name string) (Move, error) {
+	return c.GetMoveContext(context.Background(), name)
+}
+
+func (c *Config) GetMoveContext(ctx context.Context, name string) (Move, error) {
+	u, err := c.endpointURL(MoveEndpoint, name)
+	if err != nil {
+		return Move{}, err
+	}
+	return getCached[Move](ctx, c, u)
+}
+
+// The one line description of the move in lang, with its effect chance
+// filled in, e.g "Has a 10% chance to paralyze the target." Empty if there
+// isn't one.
+func (m Move) ShortEffect(lang string) string {
+	for _, e := range m.Effect_Entries {
+		if e.Language.Name != lang {
+			continue
+		}
+		effect := cleanFlavorText(e.Short_Effect)
+		if m.Effect_Chance != nil {
+			effect = strings.ReplaceAll(effect, "$effect_chance", strconv.Itoa(*m.Effect_Chance))
+		}
+		return effect
+	}
+	return ""
+}
+
+// The version groups the pokemon learns moves in, oldest first
+func (p Pokemon) MoveVersionGroups() []NamedAPIResource {
+	var groups []NamedAPIResource
+	seen := make(map[string]bool)
+	for _, m := range p.Moves {
+		for _, d := range m.Version_Group_Details {
+			if !seen[d.Version_Group.Name] {
+				seen[d.Version_Group.Name] = true
+				groups = append(groups, d.Version_Group)
+			}
+		}
+	}
+	// The API lists them in no particular order, but IDs go up with each game
+	slices.SortStableFunc(groups, func(a, b NamedAPIResource) int {
+		return cmp.Compare(resourceID(a), resourceID(b))
+	})
+	return groups
+}
+
+// The ID at the end of a resource's URL, e.g 25 for .../version-group/25/.
+// 0 if there isn't one.
+func resourceID(r NamedAPIResource) int {
+	id, _ := strconv.Atoi(path.Base(strings.TrimSuffix(r.Url, "/")))
+	return id
+}
@@ -1,21 +1,34 @@
 package pokeapi
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+	"github.com/Fraegdegjevar/pokedexcli/internal/profile"
 )
 
 func TestUpdatePagination(t *testing.T) {
 	cases := []struct {
 		name             string
 		config           *Config
+		next             string
+		previous         string
 		expectedErr      bool
 		expectedNext     string
 		expectedPrevious string
@@ -23,22 +36,47 @@ func TestUpdatePagination(t *testing.T) {
 		{
 			name:             "first page",
 			config:           &Config{},
+			next:             DefaultBaseURL + "/location-area/?offset=20&limit=20",
+			previous:         "",
 			expectedErr:      false,
-			expectedNext:     baseURL + "/location-area/?offset=20&limit=20",
+			expectedNext:     DefaultBaseURL + "/location-area/?offset=20&limit=20",
 			expectedPrevious: "",
 		},
 		{
 			name:             "second page",
 			config:           &Config{},
+			next:             DefaultBaseURL + "/location-area/?offset=40&limit=20",
+			previous:         "/location-area/?offset=0&limit=20",
 			expectedErr:      false,
-			expectedNext:     baseURL + "/location-area/?offset=40&limit=20",
-			expectedPrevious: "/location-area/?offset=0&limit=20",
+			expectedNext:     DefaultBaseURL + "/location-area/?offset=40&limit=20",
+			expectedPrevious: DefaultBaseURL + "/location-area/?offset=0&limit=20",
 		},
 		{
 			name:             "missing url",
 			config:           &Config{},
+			next:             DefaultBaseURL + "/location-area/?offset=20&limit=20",
+			previous:         "",
 			expectedErr:      false,
-			expectedNext:     baseURL + "/location-area/?offset=20&limit=20",
+			expectedNext:     DefaultBaseURL + "/location-area/?offset=20&limit=20",
+			expectedPrevious: "",
+		},
+		{
+			// Mirror hands back links to the public API - we should stay on the mirror
+			name:             "self-hosted",
+			config:           &Config{BaseURL: "http://pokeapi.internal:8000/api/v2"},
+			next:             DefaultBaseURL + "/location-area/?offset=40&limit=20",
+			previous:         "http://localhost/api/v2/location-area/?offset=0&limit=20",
+			expectedErr:      false,
+			expectedNext:     "http://pokeapi.internal:8000/api/v2/location-area/?offset=40&limit=20",
+			expectedPrevious: "http://pokeapi.internal:8000/api/v2/location-area/?offset=0&limit=20",
+		},
+		{
+			name:             "base url without api root",
+			config:           &Config{BaseURL: "http://127.0.0.1:8080"},
+			next:             "http://127.0.0.1:8080/location-area/?offset=20&limit=20",
+			previous:         "",
+			expectedErr:      false,
+			expectedNext:     "http://127.0.0.1:8080/location-area/?offset=20&limit=20",
 			expectedPrevious: "",
 		},
 	}
@@ -46,7 +84,7 @@ func TestUpdatePagination(t *testing.T) {
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			tt := tt
-			resp := &NamedAPIResourceList{Next: tt.expectedNext, Previous: tt.expectedPrevious}
+			resp := &NamedAPIResourceList{Next: tt.next, Previous: tt.previous}
 			err := tt.config.UpdatePagination(resp)
 
 			if (err != nil) != tt.expectedErr {
@@ -64,7 +102,7 @@ func TestUpdatePagination(t *testing.T) {
 	}
 }
 
-func TestRequestLocationAreas(t *testing.T) {
+func TestFetchLocationAreas(t *testing.T) {
 	// Important that we test how we handle output/format from the api.
 	// Not the api itself. So we mock-up an api and check we parse responses properly
 
@@ -156,7 +194,7 @@ func TestRequestLocationAreas(t *testing.T) {
 				t.Fatalf("error parsing URL %v for test case %v: %v ", tt.path, tt.name, err)
 			}
 			// Get response
-			resp, err := RequestLocationAreas(u)
+			resp, _, err := fetch[NamedAPIResourceList](context.Background(), NewClient(2*time.Second), u, pokecache.Validators{})
 			// First check if our error received matches what we expected
 			if (err != nil) != tt.expectedErr {
 				t.Errorf("Expected error: %v, got error: %v", tt.expectedErr, err)
@@ -174,17 +212,30 @@ func TestRequestLocationAreas(t *testing.T) {
 // that the function hits cache when cache has the value it needs
 // So we need to test it finds cache values added manually. And we
 // need to test if takes in a locationarea from a call to an injected
-// RequestLocationArea function AND updates cache to contain the response.
+// Client AND updates cache to contain the response.
 
-func TestGetLocationArea(t *testing.T) {
+// Lets a test stand in for the network by giving a Client a function
+// as its transport.
+type roundTripFunc func(*http.Request) (*http.Response, error)
 
-	//Create local overrise of RequestLocationArea
-	original := requestLocationArea
-	//ensure we swap back to the original before further tests run.
-	defer func() { requestLocationArea = original }()
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
 
-	requestLocationArea = func(_ *url.URL) (LocationArea, error) {
-		return LocationArea{ID: 1,
+// Build a Client whose every request is answered with body.
+func stubClient(body func() []byte) *Client {
+	return &Client{HTTP: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		rec.Write(body())
+		return rec.Result(), nil
+	})}}
+}
+
+func TestGetLocationArea(t *testing.T) {
+
+	//Client that answers every request with test-area-1
+	client := stubClient(func() []byte {
+		body, err := json.Marshal(LocationArea{ID: 1,
 			Name: "Test-Area-1",
 			Pokemon_Encounters: []PokemonEncounter{
 				{
@@ -200,8 +251,12 @@ func TestGetLocationArea(t *testing.T) {
 					},
 				},
 			},
-		}, nil
-	}
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal test LocationArea: %v", err)
+		}
+		return body
+	})
 	// Add data for test-area-2 to cache
 	cacheResp, err := json.Marshal(LocationArea{
 		ID:   2,
@@ -250,9 +305,10 @@ func TestGetLocationArea(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt := tt
 			// Create local config
-			conf := &Config{Cache: pokecache.NewCache(1 * time.Hour)}
+			conf := &Config{Cache: pokecache.NewCache(1 * time.Hour), Client: client}
+			defer conf.Cache.Close()
 
-			u, err := url.Parse(baseURL)
+			u, err := url.Parse(DefaultBaseURL)
 			if err != nil {
 				t.Fatalf("%s test failed to run, could not parse inputUrl: %v", tt.name, err)
 			}
@@ -302,7 +358,7 @@ func TestGetLocationArea(t *testing.T) {
 	}
 }
 
-func TestRequestLocationArea(t *testing.T) {
+func TestFetchLocationArea(t *testing.T) {
 	//Once again, we want to test how the function requests and handles
 	// a response, not the underlying API. So we mock the JSON and http server
 	mockJSON := `{
@@ -396,7 +452,7 @@ func TestRequestLocationArea(t *testing.T) {
 				t.Fatalf("failed to parse url for test %v: %v", tt.name, err)
 			}
 
-			resp, err := RequestLocationArea(u)
+			resp, _, err := fetch[LocationArea](context.Background(), NewClient(2*time.Second), u, pokecache.Validators{})
 
 			if (err != nil) != tt.expectedErr {
 				t.Errorf("Expected error: %v, got: %v", tt.expectedErr, err)
@@ -411,7 +467,7 @@ func TestRequestLocationArea(t *testing.T) {
 }
 
 // Mock up http server and test how we handle responses
-func TestRequestPokemon(t *testing.T) {
+func TestFetchPokemon(t *testing.T) {
 	mockJSON := `{
 		"id": 1,
 		"name": "pikachu",
@@ -473,8 +529,8 @@ func TestRequestPokemon(t *testing.T) {
 			// construct correct url/path to go to test server
 			u = u.JoinPath(tt.inputName)
 			t.Logf("***")
-			// hit test server with RequestPokemon
-			pokemon, err := RequestPokemon(u)
+			// hit test server with fetch
+			pokemon, _, err := fetch[Pokemon](context.Background(), NewClient(2*time.Second), u, pokecache.Validators{})
 
 			if (err != nil) != tt.expectedError {
 				t.Errorf("expected error: %v but error value was: %v", tt.expectedError, err)
@@ -487,3 +543,784 @@ func TestRequestPokemon(t *testing.T) {
 	}
 
 }
+
+// Fire off several GetLocationArea calls for the same area at once and
+// check only one of them actually makes a request.
+func TestGetLocationAreaCoalesces(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client := stubClient(func() []byte {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		// Hold the request open until all callers are waiting on it
+		<-release
+		return []byte(`{"id": 1, "name": "Test-Area-1"}`)
+	})
+
+	conf := &Config{Cache: pokecache.NewCache(1 * time.Hour), Client: client}
+	defer conf.Cache.Close()
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make(chan LocationArea, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			area, err := conf.GetLocationArea("test-area-1")
+			if err != nil {
+				t.Errorf("GetLocationArea returned error: %v", err)
+			}
+			results <- area
+		}()
+	}
+
+	// Once the API has the first request, wait for everyone else to be
+	// waiting on it rather than making requests of their own
+	<-started
+	u, _ := conf.endpointURL(LocationAreaEndpoint, "test-area-1")
+	deadline := time.Now().Add(5 * time.Second)
+	for conf.flights.waiting(u.String()) < callers-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for callers to join the request, %d waiting", conf.flights.waiting(u.String()))
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 request for %d concurrent callers, got: %d", callers, calls.Load())
+	}
+	for area := range results {
+		if area.Name != "Test-Area-1" {
+			t.Errorf("expected every caller to get Test-Area-1, got: %v", area.Name)
+		}
+	}
+}
+
+// An expired entry on disk should be revalidated with its ETag and, on a 304,
+// served from the cache and marked fresh again.
+func TestFlightGroupPanic(t *testing.T) {
+	var g flightGroup
+	func() {
+		defer func() { recover() }()
+		g.Do(context.Background(), "key", func() (any, error) { panic("boom") })
+	}()
+
+	// The key is forgotten, so the next call runs rather than hanging
+	val, shared, err := g.Do(context.Background(), "key", func() (any, error) { return "ok", nil })
+	if val != "ok" || shared || err != nil {
+		t.Errorf("expected a fresh call after a panic, got: %v %v %v", val, shared, err)
+	}
+}
+
+func TestGetPokemonRevalidates(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"id": 25, "name": "pikachu", "base_experience": 112}`)
+	}))
+	defer server.Close()
+
+	disk, err := pokecache.NewDiskStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("could not create disk store: %v", err)
+	}
+	var log bytes.Buffer
+	conf := &Config{
+		Cache:   pokecache.NewCacheWithDisk(1*time.Hour, disk),
+		Client:  NewClient(2 * time.Second),
+		BaseURL: server.URL,
+		Log:     &log,
+	}
+	defer conf.Close()
+
+	cases := []struct {
+		name             string
+		expire           bool
+		expectedRequests int
+		expectedLog      string
+	}{
+		{name: "first fetch", expectedRequests: 1, expectedLog: "Cache miss"},
+		{name: "fresh cache hit", expectedRequests: 1, expectedLog: "Cache hit"},
+		{name: "expired entry revalidated", expire: true, expectedRequests: 2, expectedLog: "Revalidated cached url"},
+		{name: "fresh again after 304", expectedRequests: 2, expectedLog: "Cache hit"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expire {
+				// Drop the memory tier and make the disk entry look old
+				conf.Cache.SetLimits(1, 0)
+				conf.Cache.Add("unrelated", []byte{})
+				disk.TTL = time.Nanosecond
+				time.Sleep(time.Millisecond)
+				defer func() { disk.TTL = time.Hour }()
+			}
+
+			pokemon, err := conf.GetPokemon("pikachu")
+			if err != nil {
+				t.Fatalf("GetPokemon returned error: %v", err)
+			}
+			if pokemon.Name != "pikachu" {
+				t.Errorf("expected pokemon name: pikachu got: %v", pokemon.Name)
+			}
+			if requests != tt.expectedRequests {
+				t.Errorf("expected %v requests to the API, got: %v", tt.expectedRequests, requests)
+			}
+			// Cache messages go to conf.Log
+			if !strings.Contains(log.String(), tt.expectedLog) {
+				t.Errorf("expected log to contain %q, got: %q", tt.expectedLog, log.String())
+			}
+			log.Reset()
+		})
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bad-json":
+			fmt.Fprintln(w, `{"id": {}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name        string
+		URL         string
+		checkErrFn  func(error) bool
+		description string
+	}{
+		{
+			name: "not found",
+			URL:  server.URL + "/missingno",
+			checkErrFn: func(err error) bool {
+				var statusErr *StatusError
+				return errors.As(err, &statusErr) && statusErr.NotFound()
+			},
+			description: "*StatusError with NotFound",
+		},
+		{
+			name: "bad json",
+			URL:  server.URL + "/bad-json",
+			checkErrFn: func(err error) bool {
+				var decodeErr *DecodeError
+				return errors.As(err, &decodeErr)
+			},
+			description: "*DecodeError",
+		},
+		{
+			name: "no response from api",
+			URL:  "http://192.0.2.1:12345/doesnt-matter",
+			checkErrFn: func(err error) bool {
+				var requestErr *RequestError
+				return errors.As(err, &requestErr)
+			},
+			description: "*RequestError",
+		},
+	}
+
+	client := NewClient(200 * time.Millisecond)
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.URL)
+			if err != nil {
+				t.Fatalf("error parsing URL for test %v: %v", tt.name, err)
+			}
+			_, _, err = fetch[Pokemon](context.Background(), client, u, pokecache.Validators{})
+			if !tt.checkErrFn(err) {
+				t.Errorf("expected error to be %v, got: %v (%T)", tt.description, err, err)
+			}
+		})
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	cases := []struct {
+		name             string
+		statuses         []int
+		retryAfter       string
+		maxAttempts      int
+		expectedErr      bool
+		expectedRequests int
+		expectedWaits    []time.Duration
+	}{
+		{
+			name:             "recovers from 5xx",
+			statuses:         []int{503, 502, 200},
+			maxAttempts:      3,
+			expectedErr:      false,
+			expectedRequests: 3,
+		},
+		{
+			name:             "gives up after max attempts",
+			statuses:         []int{500, 500, 500, 200},
+			maxAttempts:      3,
+			expectedErr:      true,
+			expectedRequests: 3,
+		},
+		{
+			name:             "honours retry-after on 429",
+			statuses:         []int{429, 200},
+			retryAfter:       "2",
+			maxAttempts:      3,
+			expectedErr:      false,
+			expectedRequests: 2,
+			expectedWaits:    []time.Duration{2 * time.Second},
+		},
+		{
+			name:             "retry-after longer than max delay",
+			statuses:         []int{429, 200},
+			retryAfter:       "3600",
+			maxAttempts:      3,
+			expectedErr:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "404 is not retried",
+			statuses:         []int{404, 200},
+			maxAttempts:      3,
+			expectedErr:      true,
+			expectedRequests: 1,
+		},
+		{
+			name:             "retries disabled",
+			statuses:         []int{503, 200},
+			maxAttempts:      1,
+			expectedErr:      true,
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.statuses[requests]
+				requests++
+				if status != http.StatusOK {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(status)
+					return
+				}
+				fmt.Fprintln(w, `{"id": 25, "name": "pikachu"}`)
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			client := NewClient(2 * time.Second)
+			client.Retry = RetryPolicy{MaxAttempts: tt.maxAttempts, BaseDelay: time.Millisecond, MaxDelay: time.Minute}
+			client.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			u, _ := url.Parse(server.URL + "/pikachu")
+			pokemon, _, err := fetch[Pokemon](context.Background(), client, u, pokecache.Validators{})
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("expected error: %v, got: %v", tt.expectedErr, err)
+			}
+			if !tt.expectedErr && pokemon.Name != "pikachu" {
+				t.Errorf("expected pokemon name: pikachu got: %v", pokemon.Name)
+			}
+			if requests != tt.expectedRequests {
+				t.Errorf("expected %v requests, got: %v", tt.expectedRequests, requests)
+			}
+			if tt.expectedWaits != nil && fmt.Sprint(waits) != fmt.Sprint(tt.expectedWaits) {
+				t.Errorf("expected waits: %v, got: %v", tt.expectedWaits, waits)
+			}
+		})
+	}
+}
+
+// A request that can't even be built fails straight away, without backoff
+func TestClientBadURLNotRetried(t *testing.T) {
+	var waits []time.Duration
+	client := NewClient(2 * time.Second)
+	client.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	u := &url.URL{Scheme: "http", Host: "bad host"}
+	_, _, err := fetch[Pokemon](context.Background(), client, u, pokecache.Validators{})
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) || requestErr.Temporary {
+		t.Errorf("expected a RequestError that isn't temporary, got: %#v", err)
+	}
+	if len(waits) != 0 {
+		t.Errorf("expected no retries, got waits: %v", waits)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := min(policy.BaseDelay<<(attempt-1), policy.MaxDelay)
+		for i := 0; i < 20; i++ {
+			if d := policy.backoff(attempt); d < 0 || d > ceiling {
+				t.Fatalf("attempt %v: expected backoff between 0 and %v, got: %v", attempt, ceiling, d)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		header   string
+		expected time.Duration
+	}{
+		{header: "", expected: 0},
+		{header: "5", expected: 5 * time.Second},
+		{header: "Mon, 01 Jan 2024 12:00:30 GMT", expected: 30 * time.Second},
+		{header: "Mon, 01 Jan 2024 11:00:00 GMT", expected: 0},
+		{header: "soon", expected: 0},
+	}
+	for _, tt := range cases {
+		if actual := parseRetryAfter(tt.header, now); actual != tt.expected {
+			t.Errorf("Retry-After %q: expected %v, got: %v", tt.header, tt.expected, actual)
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// Fake clock so we can step time along without waiting
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, 3)
+	limiter.last = now
+	limiter.now = func() time.Time { return now }
+
+	// Steps happen in order: advance the clock, then take a token
+	cases := []struct {
+		name         string
+		advance      time.Duration
+		expectedWait time.Duration
+	}{
+		{name: "burst 1", expectedWait: 0},
+		{name: "burst 2", expectedWait: 0},
+		{name: "burst 3", expectedWait: 0},
+		{name: "bucket empty", expectedWait: 500 * time.Millisecond},
+		{name: "queued behind previous waiter", expectedWait: time.Second},
+		{name: "refilled after waiting", advance: 2 * time.Second, expectedWait: 0},
+		{name: "refill capped at burst", advance: time.Hour, expectedWait: 0},
+	}
+
+	for _, tt := range cases {
+		now = now.Add(tt.advance)
+		if wait := limiter.reserve(); wait != tt.expectedWait {
+			t.Errorf("%v: expected wait %v, got: %v", tt.name, tt.expectedWait, wait)
+		}
+	}
+
+	// After an hour the bucket should be back to full (3) minus the one just taken
+	if limiter.tokens != 2 {
+		t.Errorf("expected 2 tokens left, got: %v", limiter.tokens)
+	}
+}
+
+// Cache hits shouldn't touch the limiter, only real requests.
+func TestCacheHitsDontConsumeTokens(t *testing.T) {
+	client := stubClient(func() []byte { return []byte(`{"id": 25, "name": "pikachu"}`) })
+	client.Limiter = NewRateLimiter(1, 1)
+	var waits []time.Duration
+	client.Limiter.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	conf := &Config{Cache: pokecache.NewCache(time.Hour), Client: client}
+	defer conf.Close()
+
+	for i := 0; i < 5; i++ {
+		if _, err := conf.GetPokemon("pikachu"); err != nil {
+			t.Fatalf("GetPokemon returned error: %v", err)
+		}
+	}
+	if len(waits) != 0 {
+		t.Errorf("expected no waits for one request and four cache hits, got: %v", waits)
+	}
+}
+
+// Cancelling the context should abort a slow request rather than waiting
+// for the client timeout.
+func TestGetPokemonCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hang until the client goes away
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	conf := &Config{Cache: pokecache.NewCache(time.Hour), Client: NewClient(time.Minute), BaseURL: server.URL}
+	defer conf.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := conf.GetPokemonContext(ctx, "pikachu")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected request to be abandoned promptly, took: %v", elapsed)
+	}
+}
+
+func TestSaveAndLoadPokedex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+	conf := &Config{SaveFile: path, Pokedex: map[string]Pokemon{
+		"pikachu": {ID: 25, Name: "pikachu", Base_Experience: 112, Moves: []PokemonMove{{Move: NamedAPIResource{Name: "thunder-shock"}}}},
+	}}
+	if err := conf.SavePokedex(); err != nil {
+		t.Fatalf("SavePokedex returned error: %v", err)
+	}
+
+	loaded := &Config{SaveFile: path}
+	if err := loaded.LoadPokedex(); err != nil {
+		t.Fatalf("LoadPokedex returned error: %v", err)
+	}
+	if loaded.Pokedex["pikachu"].ID != 25 {
+		t.Errorf("expected pikachu with id 25 to be loaded, got: %v", loaded.Pokedex)
+	}
+	// Learnsets aren't saved
+	if loaded.Pokedex["pikachu"].Moves != nil {
+		t.Errorf("expected moves not to be saved, got: %v", loaded.Pokedex["pikachu"].Moves)
+	}
+	if conf.Pokedex["pikachu"].Moves == nil {
+		t.Errorf("expected saving to leave the moves in memory alone")
+	}
+}
+
+// Explored areas are saved so coverage can suggest catches after a restart
+func TestSaveAndLoadExplored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+	conf := &Config{SaveFile: path, Pokedex: map[string]Pokemon{}}
+	conf.seen.addExplored(LocationArea{Name: "lake-verity-front", Pokemon_Encounters: []PokemonEncounter{
+		{Pokemon: NamedAPIResource{Name: "gyarados"}},
+		{Pokemon: NamedAPIResource{Name: "magikarp"}},
+	}})
+	if err := conf.SavePokedex(); err != nil {
+		t.Fatalf("SavePokedex returned error: %v", err)
+	}
+
+	loaded := &Config{SaveFile: path}
+	if err := loaded.LoadPokedex(); err != nil {
+		t.Fatalf("LoadPokedex returned error: %v", err)
+	}
+	expected := map[string][]string{"lake-verity-front": {"gyarados", "magikarp"}}
+	if fmt.Sprint(loaded.ExploredAreas()) != fmt.Sprint(expected) {
+		t.Errorf("expected explored areas %v, got: %v", expected, loaded.ExploredAreas())
+	}
+	// The saved names are offered for completion too
+	if !slices.Contains(loaded.KnownPokemon(), "magikarp") || !slices.Contains(loaded.SeenLocationAreas(), "lake-verity-front") {
+		t.Errorf("expected saved names in completion, got pokemon %v and areas %v", loaded.KnownPokemon(), loaded.SeenLocationAreas())
+	}
+
+	// A profile with no save file starts with nothing explored
+	loaded.SaveFile = filepath.Join(t.TempDir(), "pokedex.json")
+	if err := loaded.LoadPokedex(); err != nil {
+		t.Fatalf("LoadPokedex returned error: %v", err)
+	}
+	if len(loaded.ExploredAreas()) != 0 {
+		t.Errorf("expected no explored areas from a missing save, got: %v", loaded.ExploredAreas())
+	}
+}
+
+// A save written against one base URL and loaded with another should page
+// through the new one, not the URL stored in the file.
+func TestLoadedPagesUseBaseURL(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		fmt.Fprintln(w, `{"count": 0, "results": []}`)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+	saved := &Config{SaveFile: path, Pokedex: map[string]Pokemon{}}
+	saved.Next, _ = url.Parse(DefaultBaseURL + "/location-area/?offset=20&limit=20")
+	if err := saved.SavePokedex(); err != nil {
+		t.Fatalf("SavePokedex returned error: %v", err)
+	}
+
+	conf := &Config{
+		SaveFile: path,
+		Cache:    pokecache.NewCache(time.Hour),
+		Client:   NewClient(2 * time.Second),
+		BaseURL:  server.URL + "/api/v2",
+	}
+	defer conf.Close()
+	if err := conf.LoadPokedex(); err != nil {
+		t.Fatalf("LoadPokedex returned error: %v", err)
+	}
+	_, err := conf.GetLocationAreas(conf.Next)
+	if err != nil {
+		t.Fatalf("GetLocationAreas returned error: %v", err)
+	}
+	if requested != "/api/v2/location-area/?offset=20&limit=20" {
+		t.Errorf("expected the saved page to be fetched from the test server, got request: %q", requested)
+	}
+}
+
+func TestLoadPokedex(t *testing.T) {
+	cases := []struct {
+		name            string
+		fileContents    string
+		writeFile       bool
+		expectedErr     bool
+		expectedPokemon []string
+		expectedNext    string
+	}{
+		{
+			name:            "missing file",
+			writeFile:       false,
+			expectedErr:     false,
+			expectedPokemon: []string{},
+		},
+		{
+			name:            "current version",
+			fileContents:    `{"version": 1, "pokemon": {"pikachu": {"id": 25, "name": "pikachu"}}, "next": "https://pokeapi.co/api/v2/location-area/?offset=20&limit=20"}`,
+			writeFile:       true,
+			expectedErr:     false,
+			expectedPokemon: []string{"pikachu"},
+			expectedNext:    "https://pokeapi.co/api/v2/location-area/?offset=20&limit=20",
+		},
+		{
+			// The map position is optional
+			name:            "no map position",
+			fileContents:    `{"version": 1, "pokemon": {"pikachu": {"id": 25, "name": "pikachu"}}}`,
+			writeFile:       true,
+			expectedErr:     false,
+			expectedPokemon: []string{"pikachu"},
+		},
+		{
+			name:         "no version",
+			fileContents: `{"pikachu": {"id": 25, "name": "pikachu"}}`,
+			writeFile:    true,
+			expectedErr:  true,
+		},
+		{
+			name:         "version 0",
+			fileContents: `{"version": 0, "pokemon": {}}`,
+			writeFile:    true,
+			expectedErr:  true,
+		},
+		{
+			name:         "newer version",
+			fileContents: `{"version": 99, "pokemon": {}}`,
+			writeFile:    true,
+			expectedErr:  true,
+		},
+		{
+			name:         "corrupt file",
+			fileContents: `{"version": 1, "pokemon": {`,
+			writeFile:    true,
+			expectedErr:  true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "pokedex.json")
+			if tt.writeFile {
+				if err := os.WriteFile(path, []byte(tt.fileContents), 0o644); err != nil {
+					t.Fatalf("could not write save file: %v", err)
+				}
+			}
+
+			conf := &Config{SaveFile: path}
+			err := conf.LoadPokedex()
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error: %v, got: %v", tt.expectedErr, err)
+			}
+			if tt.expectedErr {
+				return
+			}
+			if len(conf.Pokedex) != len(tt.expectedPokemon) {
+				t.Errorf("expected %v pokemon, got: %v", len(tt.expectedPokemon), conf.Pokedex)
+			}
+			for _, name := range tt.expectedPokemon {
+				if _, found := conf.Pokedex[name]; !found {
+					t.Errorf("expected %v in loaded pokedex", name)
+				}
+			}
+			if urlString(conf.Next) != tt.expectedNext {
+				t.Errorf("expected next page: %v, got: %v", tt.expectedNext, urlString(conf.Next))
+			}
+		})
+	}
+}
+
+func TestSwitchProfile(t *testing.T) {
+	dir := t.TempDir()
+	profiles, err := profile.NewManager(filepath.Join(dir, "profiles"), filepath.Join(dir, "pokedex.json"))
+	if err != nil {
+		t.Fatalf("could not create profile manager: %v", err)
+	}
+	if err := profiles.Create("misty"); err != nil {
+		t.Fatalf("could not create profile: %v", err)
+	}
+
+	var switchedTo string
+	conf := &Config{
+		Profiles:        profiles,
+		SaveFile:        profiles.SaveFile(profile.DefaultProfile),
+		Pokedex:         map[string]Pokemon{"pikachu": {Name: "pikachu"}},
+		OnProfileSwitch: func(name string) error { switchedTo = name; return nil },
+	}
+	conf.Next, _ = url.Parse(DefaultBaseURL + "/location-area/?offset=40&limit=20")
+	conf.seen.addExplored(LocationArea{Name: "viridian-forest-area"})
+
+	// New profile starts empty, on the first page and with nothing explored
+	if err := conf.SwitchProfile("misty"); err != nil {
+		t.Fatalf("SwitchProfile(misty) returned error: %v", err)
+	}
+	if len(conf.Pokedex) != 0 || conf.Next != nil || len(conf.ExploredAreas()) != 0 {
+		t.Errorf("expected empty pokedex on the first page, got: %v next: %v explored: %v", conf.Pokedex, conf.Next, conf.ExploredAreas())
+	}
+	if switchedTo != "misty" || profiles.Current != "misty" {
+		t.Errorf("expected misty to be current and OnProfileSwitch called, got current: %v hook: %v", profiles.Current, switchedTo)
+	}
+	conf.Pokedex["staryu"] = Pokemon{Name: "staryu"}
+
+	// Going back restores the default profile's pokedex and position
+	if err := conf.SwitchProfile(profile.DefaultProfile); err != nil {
+		t.Fatalf("SwitchProfile(default) returned error: %v", err)
+	}
+	if _, found := conf.Pokedex["pikachu"]; !found || len(conf.Pokedex) != 1 {
+		t.Errorf("expected only pikachu in default pokedex, got: %v", conf.Pokedex)
+	}
+	if urlString(conf.Next) != DefaultBaseURL+"/location-area/?offset=40&limit=20" {
+		t.Errorf("expected map position to be restored, got: %v", urlString(conf.Next))
+	}
+	if _, found := conf.ExploredAreas()["viridian-forest-area"]; !found {
+		t.Errorf("expected explored areas to be restored, got: %v", conf.ExploredAreas())
+	}
+
+	if err := conf.SwitchProfile("nobody"); err == nil {
+		t.Errorf("expected switching to a missing profile to fail")
+	}
+
+	// Failing to apply the new profile's settings leaves us fully where we were
+	conf.OnProfileSwitch = func(name string) error { return fmt.Errorf("bad settings") }
+	saveFile := conf.SaveFile
+	if err := conf.SwitchProfile("misty"); err == nil {
+		t.Fatalf("expected SwitchProfile to fail when settings can't be applied")
+	}
+	if profiles.Current != profile.DefaultProfile || conf.SaveFile != saveFile {
+		t.Errorf("expected to still be on the default profile, got current: %v save file: %v", profiles.Current, conf.SaveFile)
+	}
+	if _, found := conf.Pokedex["pikachu"]; !found || urlString(conf.Next) == "" {
+		t.Errorf("expected the default pokedex and position to be kept, got: %v next: %v", conf.Pokedex, urlString(conf.Next))
+	}
+	if _, found := conf.ExploredAreas()["viridian-forest-area"]; !found {
+		t.Errorf("expected the default explored areas to be kept, got: %v", conf.ExploredAreas())
+	}
+}
+
+func TestEffectiveness(t *testing.T) {
+	named := func(names ...string) []NamedAPIResource {
+		var resources []NamedAPIResource
+		for _, name := range names {
+			resources = append(resources, NamedAPIResource{Name: name})
+		}
+		return resources
+	}
+	water := Type{Name: "water", Damage_Relations: TypeRelations{
+		Half_Damage_From:   named("fire", "water", "ice", "steel"),
+		Double_Damage_From: named("electric", "grass"),
+	}}
+	flying := Type{Name: "flying", Damage_Relations: TypeRelations{
+		No_Damage_From:     named("ground"),
+		Half_Damage_From:   named("grass", "fighting", "bug"),
+		Double_Damage_From: named("electric", "ice", "rock"),
+	}}
+
+	cases := []struct {
+		attacking string
+		defending []Type
+		expected  float64
+	}{
+		{attacking: "electric", defending: []Type{water}, expected: 2},
+		{attacking: "normal", defending: []Type{water}, expected: 1},
+		// Dual types multiply
+		{attacking: "electric", defending: []Type{water, flying}, expected: 4},
+		{attacking: "steel", defending: []Type{water, flying}, expected: 0.5},
+		{attacking: "grass", defending: []Type{water, flying}, expected: 1},
+		{attacking: "ground", defending: []Type{water, flying}, expected: 0},
+		{attacking: "fire", defending: nil, expected: 1},
+	}
+	for _, tt := range cases {
+		if actual := Effectiveness(tt.attacking, tt.defending); actual != tt.expected {
+			t.Errorf("%v against %v types: expected %v, got: %v", tt.attacking, len(tt.defending), tt.expected, actual)
+		}
+	}
+
+	// Only the types that don't do normal damage, so not grass or ice which cancel out
+	chart := DefensiveChart([]Type{water, flying})
+	expected := map[string]float64{"electric": 4, "rock": 2, "fire": 0.5, "water": 0.5, "steel": 0.5, "fighting": 0.5, "bug": 0.5, "ground": 0}
+	if fmt.Sprint(chart) != fmt.Sprint(expected) {
+		t.Errorf("Expected chart %v, got: %v", expected, chart)
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	// Slots aren't always in order in the JSON
+	pokemon := Pokemon{Types: []PokemonType{
+		{Slot: 2, Type: NamedAPIResource{Name: "flying"}},
+		{Slot: 1, Type: NamedAPIResource{Name: "water"}},
+	}}
+	if names := fmt.Sprint(pokemon.TypeNames()); names != "[water flying]" {
+		t.Errorf("Expected [water flying], got: %v", names)
+	}
+}
+
+func TestMoveShortEffect(t *testing.T) {
+	chance := 30
+	move := Move{Effect_Chance: &chance, Effect_Entries: []VerboseEffect{
+		{Short_Effect: "Hat eine Chance", Language: NamedAPIResource{Name: "de"}},
+		{Short_Effect: "Has a $effect_chance% chance to\nburn the target.", Language: NamedAPIResource{Name: "en"}},
+	}}
+	if effect := move.ShortEffect("en"); effect != "Has a 30% chance to burn the target." {
+		t.Errorf("Expected the English effect with its chance filled in, got: %q", effect)
+	}
+	if effect := move.ShortEffect("fr"); effect != "" {
+		t.Errorf("Expected no effect in French, got: %q", effect)
+	}
+}
+
+// Fetched pokemon stay in completion once they've left the memory cache
+func TestKnownPokemon(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"id": 25, "name": "pikachu"}`)
+	}))
+	defer server.Close()
+
+	conf := &Config{
+		Cache:   pokecache.NewCache(time.Hour),
+		Client:  NewClient(2 * time.Second),
+		BaseURL: server.URL,
+		Pokedex: map[string]Pokemon{"eevee": {Name: "eevee"}},
+	}
+	defer conf.Close()
+
+	if _, err := conf.GetPokemon("25"); err != nil {
+		t.Fatalf("GetPokemon returned error: %v", err)
+	}
+	// Push the response out of memory, as the reaper would
+	conf.Cache.SetLimits(1, 0)
+	conf.Cache.Add("unrelated", []byte{})
+
+	known := conf.KnownPokemon()
+	expected := []string{"eevee", "pikachu"}
+	if !slices.Equal(known, expected) {
+		t.Errorf("expected known pokemon %v, got: %v", expected, known)
+	}
+}
@@ -0,0 +1,61 @@
+package pokeapi
+
+import "fmt"
+
+// Switch to another trainer profile: save the current profile's Pokedex, map
+// position and explored areas, then load the new profile's. OnProfileSwitch (if set) is
+// called so the caller can apply the new profile's settings. If any step
+// fails we stay in the current profile.
+func (c *Config) SwitchProfile(name string) error {
+	if c.Profiles == nil {
+		return fmt.Errorf("profiles are not available")
+	}
+	if !c.Profiles.Exists(name) {
+		return fmt.Errorf("no profile called %v", name)
+	}
+
+	// Don't lose anything from the profile we are leaving
+	err := c.SavePokedex()
+	if err != nil {
+		return err
+	}
+
+	// If anything below fails, put everything back so we are still fully in
+	// the profile we started in - the save file, Pokedex, map position and
+	// explored areas must always belong to Profiles.Current.
+	previous := c.Profiles.Current
+	saveFile, pokedex, next, prev := c.SaveFile, c.Pokedex, c.Next, c.Previous
+	explored := c.ExploredAreas()
+	rollback := func() {
+		c.SaveFile, c.Pokedex, c.Next, c.Previous = saveFile, pokedex, next, prev
+		c.seen.setExplored(explored)
+	}
+
+	c.SaveFile = c.Profiles.SaveFile(name)
+	err = c.LoadPokedex()
+	if err != nil {
+		rollback()
+		return err
+	}
+
+	if c.OnProfileSwitch != nil {
+		err = c.OnProfileSwitch(name)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("could not apply settings for %v: %v", name, err)
+		}
+	}
+
+	err = c.Profiles.SetCurrent(name)
+	if err != nil {
+		rollback()
+		// The new profile's settings were applied, so go back to ours
+		if c.OnProfileSwitch != nil {
+			if restoreErr := c.OnProfileSwitch(previous); restoreErr != nil {
+				return fmt.Errorf("%v (and could not restore settings for %v: %v)", err, previous, restoreErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package pokeapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Client side rate limiting so bulk operations (e.g crawling every location
+// area) stay within PokeAPI's fair use policy. Only real requests take a
+// token - cache hits never reach the Client.
+
+const (
+	DefaultRate  = 10.0 // requests per second
+	DefaultBurst = 20
+)
+
+// Token bucket. The bucket holds up to burst tokens and refills at rate tokens
+// per second. Each request takes one token, waiting for it if the bucket is empty.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// Replaced in tests so we don't actually wait. sleep is nil outside tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// The bucket starts full so the first burst requests go straight through.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Block until a token is available and take it. Returns early with ctx's
+// error if ctx is cancelled while waiting, and the token is handed back.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	d := l.reserve()
+	if d <= 0 {
+		return nil
+	}
+	if l.sleep != nil {
+		l.sleep(d)
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Take a token and return how long the caller must wait before using it.
+// Tokens can go negative - each waiter then owes time for its place in the
+// queue, so concurrent callers are spaced out rather than all waking at once.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	l.tokens = min(l.tokens+elapsed*l.rate, l.burst)
+
+	l.tokens--
+	if l.tokens >= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
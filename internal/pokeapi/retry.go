@@ -0,0 +1,125 @@
+package pokeapi
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+)
+
+// Retrying of failed GETs. A single timeout or 5xx from the API shouldn't
+// make a command fail outright. We only ever send GETs, which are safe to repeat.
+
+// MaxAttempts is the total number of tries, so 1 means no retries. Delays
+// double from BaseDelay each attempt, capped at MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// GET with retries on network errors, 429 Too Many Requests and 5xx statuses.
+// Gives up straight away if ctx is cancelled.
+func (c *Client) get(ctx context.Context, u *url.URL, v pokecache.Validators) (apiResponse, error) {
+	attempts := max(c.Retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		resp, retryAfter, err := c.getOnce(ctx, u, v)
+		if err == nil || ctx.Err() != nil || !retryable(err) || attempt == attempts {
+			return resp, err
+		}
+
+		// Honour the server asking us to wait, as long as it isn't
+		// longer than we are willing to wait at all.
+		delay := c.Retry.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > c.Retry.MaxDelay {
+				return resp, err
+			}
+			delay = retryAfter
+		}
+
+		c.logf("Attempt %d/%d for %v failed: %v - retrying in %v\n", attempt, attempts, u, err, delay.Round(time.Millisecond))
+		if err := c.wait(ctx, delay); err != nil {
+			return apiResponse{}, err
+		}
+	}
+}
+
+// Exponential backoff with "full jitter" - a random delay between 0 and the
+// capped exponential delay, so many clients failing at once don't all retry
+// in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	// Guard the shift against overflow on silly attempt counts
+	if attempt < 32 {
+		delay = min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// Network errors, 429 and 5xx are worth another go. Anything else (bad URL,
+// 404, bad JSON...) will fail the same way next time.
+func retryable(err error) bool {
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr.Temporary
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500
+	}
+	return false
+}
+
+// Retry-After is either a number of seconds or an HTTP date.
+// Returns 0 if missing or unparseable.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil && when.After(now) {
+		return when.Sub(now)
+	}
+	return 0
+}
+
+// Sleep for d, or until ctx is cancelled.
+func (c *Client) wait(ctx context.Context, d time.Duration) error {
+	if c.sleep != nil {
+		c.sleep(d)
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) logf(format string, args ...any) {
+	if c.Logf != nil {
+		c.Logf(format, args...)
+	}
+}
@@ -0,0 +1,171 @@
+package pokeapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/atomicfile"
+)
+
+// Saving and loading the Pokedex so caught pokemon survive between sessions.
+// The map position (Next/Previous) and the areas explored, with the pokemon
+// found in each, are saved along with it.
+// The save file is versioned - when the format changes, bump
+// currentSaveVersion and add a migration from the previous version.
+//
+// A pokemon's learnset (Pokemon.Moves) is session-only and never written out,
+// so loaded pokemon have no Moves until they're fetched again. That needed no
+// version bump: saves from before this still have their moves, which load
+// fine, and nothing reads Moves from the Pokedex.
+
+const currentSaveVersion = 1
+
+type saveFile struct {
+	Version  int                 `json:"version"`
+	SavedAt  time.Time           `json:"saved_at"`
+	Pokemon  map[string]Pokemon  `json:"pokemon"`
+	Next     string              `json:"next,omitempty"`
+	Previous string              `json:"previous,omitempty"`
+	Explored map[string][]string `json:"explored,omitempty"`
+}
+
+// The first save format is version 1
+const firstSaveVersion = 1
+
+// saveMigrations[n] upgrades a version n save to version n+1. None yet -
+// adding optional fields (like the map position or explored areas) doesn't
+// need one.
+var saveMigrations = []func([]byte) ([]byte, error){}
+
+// Work out which version a save is. Every save has a version field.
+func saveVersion(data []byte) (int, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return 0, err
+	}
+	raw, exists := fields["version"]
+	if !exists {
+		return 0, fmt.Errorf("no version field")
+	}
+	var version int
+	err = json.Unmarshal(raw, &version)
+	if err != nil {
+		return 0, fmt.Errorf("bad version field: %v", err)
+	}
+	return version, nil
+}
+
+// Read a save file, migrating it to the current version if needed.
+func decodeSave(data []byte) (saveFile, error) {
+	version, err := saveVersion(data)
+	if err != nil {
+		return saveFile{}, err
+	}
+	if version > currentSaveVersion {
+		return saveFile{}, fmt.Errorf("save file is version %v but this pokedex only understands up to version %v", version, currentSaveVersion)
+	}
+	if version < firstSaveVersion {
+		return saveFile{}, fmt.Errorf("bad version field: %v", version)
+	}
+
+	for v := version; v < currentSaveVersion; v++ {
+		data, err = saveMigrations[v](data)
+		if err != nil {
+			return saveFile{}, fmt.Errorf("error migrating save file from version %v: %v", v, err)
+		}
+	}
+
+	var save saveFile
+	err = json.Unmarshal(data, &save)
+	if err != nil {
+		return saveFile{}, err
+	}
+	if save.Pokemon == nil {
+		save.Pokemon = make(map[string]Pokemon)
+	}
+	return save, nil
+}
+
+// Load the Pokedex and map position from c.SaveFile, replacing whatever is
+// in memory. A missing save file just means an empty Pokedex.
+func (c *Config) LoadPokedex() error {
+	data, err := os.ReadFile(c.SaveFile)
+	if errors.Is(err, os.ErrNotExist) {
+		c.Pokedex = make(map[string]Pokemon)
+		c.Next, c.Previous = nil, nil
+		c.seen.setExplored(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read save file %v: %v", c.SaveFile, err)
+	}
+
+	save, err := decodeSave(data)
+	if err != nil {
+		return fmt.Errorf("could not load save file %v: %v", c.SaveFile, err)
+	}
+	c.Pokedex = save.Pokemon
+	c.seen.setExplored(save.Explored)
+	// The saved links are kept as stored. They may be for another base URL,
+	// so GetLocationAreas rebases them when they're used - by then the
+	// profile's own base URL has been applied.
+	c.Next, c.Previous = nil, nil
+	if save.Next != "" {
+		c.Next, err = url.Parse(save.Next)
+		if err != nil {
+			return fmt.Errorf("bad next page in save file %v: %v", c.SaveFile, err)
+		}
+	}
+	if save.Previous != "" {
+		c.Previous, err = url.Parse(save.Previous)
+		if err != nil {
+			return fmt.Errorf("bad previous page in save file %v: %v", c.SaveFile, err)
+		}
+	}
+	return nil
+}
+
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+// Write the Pokedex to c.SaveFile. Does nothing if no save file is set.
+func (c *Config) SavePokedex() error {
+	if c.SaveFile == "" {
+		return nil
+	}
+
+	// Learnsets are big and the same for every pokemon of a kind, so leave
+	// them out - the moves command gets them from the cache or API.
+	pokedex := make(map[string]Pokemon, len(c.Pokedex))
+	for name, pokemon := range c.Pokedex {
+		pokemon.Moves = nil
+		pokedex[name] = pokemon
+	}
+
+	data, err := json.MarshalIndent(saveFile{
+		Version:  currentSaveVersion,
+		SavedAt:  time.Now(),
+		Pokemon:  pokedex,
+		Next:     urlString(c.Next),
+		Previous: urlString(c.Previous),
+		Explored: c.ExploredAreas(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding save file: %v", err)
+	}
+
+	err = atomicfile.WriteFile(c.SaveFile, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not save pokedex: %v", err)
+	}
+	return nil
+}
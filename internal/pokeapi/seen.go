@@ -0,0 +1,140 @@
+package pokeapi
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Names of location areas and pokemon we've seen in API responses, so the
+// REPL can offer them for tab completion. Only kept for the session, apart
+// from explored areas which are saved with the Pokedex.
+type seenNames struct {
+	mu       sync.Mutex
+	areas    map[string]struct{}
+	pokemons map[string]struct{}
+	// Pokemon found in each area explored, for coverage's suggestions
+	explored map[string][]string
+}
+
+func (s *seenNames) addLocationArea(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.areas == nil {
+		s.areas = make(map[string]struct{})
+	}
+	s.areas[name] = struct{}{}
+}
+
+func (s *seenNames) addPokemon(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pokemons == nil {
+		s.pokemons = make(map[string]struct{})
+	}
+	s.pokemons[name] = struct{}{}
+}
+
+func (s *seenNames) addExplored(area LocationArea) {
+	s.addLocationArea(area.Name)
+	var found []string
+	for _, encounter := range area.Pokemon_Encounters {
+		s.addPokemon(encounter.Pokemon.Name)
+		found = append(found, encounter.Pokemon.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.explored == nil {
+		s.explored = make(map[string][]string)
+	}
+	s.explored[area.Name] = found
+}
+
+// Replace the explored areas, e.g with the ones from a save file. Their names
+// are offered for completion too.
+func (s *seenNames) setExplored(explored map[string][]string) {
+	for area, found := range explored {
+		s.addLocationArea(area)
+		for _, name := range found {
+			s.addPokemon(name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.explored = explored
+}
+
+// Location areas listed by map/mapb, explored or loaded from the save, sorted.
+func (c *Config) SeenLocationAreas() []string {
+	c.seen.mu.Lock()
+	defer c.seen.mu.Unlock()
+	return sortedNames(c.seen.areas)
+}
+
+// Location areas explored, in this session or saved ones, and the pokemon
+// that can be found in each. The map is a copy so it's safe to keep.
+func (c *Config) ExploredAreas() map[string][]string {
+	c.seen.mu.Lock()
+	defer c.seen.mu.Unlock()
+	explored := make(map[string][]string, len(c.seen.explored))
+	for area, pokemon := range c.seen.explored {
+		explored[area] = slices.Clone(pokemon)
+	}
+	return explored
+}
+
+// Pokemon we know the names of: everything in the Pokedex or in the cache, and
+// anything fetched or met exploring this session. The cache only lists what's
+// in memory, which is reaped after a few seconds, so fetched names are kept
+// in seen too. Sorted.
+func (c *Config) KnownPokemon() []string {
+	names := make(map[string]struct{})
+	for name := range c.Pokedex {
+		names[name] = struct{}{}
+	}
+
+	c.seen.mu.Lock()
+	for name := range c.seen.pokemons {
+		names[name] = struct{}{}
+	}
+	c.seen.mu.Unlock()
+
+	// Cache keys are URLs, e.g .../pokemon/pikachu
+	if c.Cache != nil {
+		for _, key := range c.Cache.Keys() {
+			if name := pokemonFromURL(key); name != "" {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	return sortedNames(names)
+}
+
+func pokemonFromURL(key string) string {
+	u, err := url.Parse(key)
+	if err != nil {
+		return ""
+	}
+	i := strings.LastIndex(u.Path, PokemonEndpoint)
+	if i < 0 {
+		return ""
+	}
+	name := strings.Trim(u.Path[i+len(PokemonEndpoint):], "/")
+	// Sub-resources like .../pikachu/encounters aren't a pokemon
+	if strings.Contains(name, "/") {
+		return ""
+	}
+	return name
+}
+
+func sortedNames(set map[string]struct{}) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package pokeapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Request coalescing. If several goroutines ask for the same URL at the same
+// time they would all miss in the cache and all hit the API. Instead the
+// first caller does the request and everyone else waits for its result.
+
+// One in-progress request. done is closed once val/err are set. waiters is
+// how many other callers are waiting on it, protected by flightGroup.mu.
+type flightCall struct {
+	done    chan struct{}
+	val     any
+	err     error
+	waiters int
+}
+
+// Zero value is ready to use.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+// Run fn for key, unless a call for key is already running - in which case
+// wait for that one and return its result. shared reports whether the result
+// came from another caller's request.
+// A waiting caller stops waiting if its own ctx is cancelled. fn runs with
+// the first caller's ctx, so if that caller is cancelled everyone waiting on
+// it gets the cancellation error too.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func() (any, error)) (val any, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if call, exists := g.calls[key]; exists {
+		call.waiters++
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.val, true, call.err
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	// Deferred so that even if fn panics the waiters are released, and the
+	// call is forgotten so later requests (e.g after the cache entry
+	// expires) go to the API again. Waiters get this error if fn never returns.
+	call.err = fmt.Errorf("request for %v did not complete", key)
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.val, call.err = fn()
+	return call.val, false, call.err
+}
+
+// How many callers are waiting on the call running for key, for tests
+func (g *flightGroup) waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if call, exists := g.calls[key]; exists {
+		return call.waiters
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package pokeapi
+
+import (
+	"context"
+	"strings"
+)
+
+// The language we show genus and flavor text in
+const DefaultLanguage = "en"
+
+// Get a pokemon species from the cache, or the API if not cached.
+func (c *Config) GetPokemonSpecies(name string) (PokemonSpecies, error) {
+	return c.GetPokemonSpeciesContext(context.Background(), name)
+}
+
+func (c *Config) GetPokemonSpeciesContext(ctx context.Context, name string) (PokemonSpecies, error) {
+	u, err := c.endpointURL(SpeciesEndpoint, name)
+	if err != nil {
+		return PokemonSpecies{}, err
+	}
+	return getCached[PokemonSpecies](ctx, c, u)
+}
+
+// Name of the pokemon's species, for GetPokemonSpecies. Pokemon saved before
+// we kept the species fall back to their own name, which is the same for
+// everything but alternate forms.
+func (p Pokemon) SpeciesName() string {
+	if p.Species.Name != "" {
+		return p.Species.Name
+	}
+	return p.Name
+}
+
+// The genus in lang, e.g "Mouse Pokémon". Empty if there isn't one.
+func (s PokemonSpecies) Genus(lang string) string {
+	for _, g := range s.Genera {
+		if g.Language.Name == lang {
+			return g.Genus
+		}
+	}
+	return ""
+}
+
+// The most recent Pokedex entry in lang. Empty if there isn't one.
+func (s PokemonSpecies) FlavorText(lang string) string {
+	// Entries are oldest game first
+	for i := len(s.Flavor_Text_Entries) - 1; i >= 0; i-- {
+		entry := s.Flavor_Text_Entries[i]
+		if entry.Language.Name == lang {
+			return cleanFlavorText(entry.Flavor_Text)
+		}
+	}
+	return ""
+}
+
+// Flavor text comes with the line breaks (and form feeds) from the game's
+// text box - join it back into one line.
+func cleanFlavorText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
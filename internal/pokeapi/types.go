@@ -0,0 +1,95 @@
+package pokeapi
+
+import (
+	"cmp"
+	"context"
+	"slices"
+)
+
+// The types moves and pokemon can have in battle. The API also has "unknown"
+// and "shadow" (and "stellar" in newer data) which never come up here.
+var BattleTypes = []string{
+	"normal", "fire", "water", "electric", "grass", "ice",
+	"fighting", "poison", "ground", "flying", "psychic", "bug",
+	"rock", "ghost", "dragon", "dark", "steel", "fairy",
+}
+
+// Get a type from the cache, or the API if not cached.
+func (c *Config) GetType(name string) (Type, error) {
+	return c.GetTypeContext(context.Background(), name)
+}
+
+func (c *Config) GetTypeContext(ctx context.Context, name string) (Type, error) {
+	u, err := c.endpointURL(TypeEndpoint, name)
+	if err != nil {
+		return Type{}, err
+	}
+	return getCached[Type](ctx, c, u)
+}
+
+// Get each of the named types, in order
+func (c *Config) GetTypesContext(ctx context.Context, names []string) ([]Type, error) {
+	types := make([]Type, 0, len(names))
+	for _, name := range names {
+		t, err := c.GetTypeContext(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// Names of the pokemon's types, primary type first
+func (p Pokemon) TypeNames() []string {
+	types := slices.Clone(p.Types)
+	slices.SortFunc(types, func(a, b PokemonType) int {
+		return cmp.Compare(a.Slot, b.Slot)
+	})
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, t.Type.Name)
+	}
+	return names
+}
+
+// Multiplier for a move of the attacking type hitting this type
+func (t Type) DamageFrom(attacking string) float64 {
+	switch {
+	case hasName(t.Damage_Relations.No_Damage_From, attacking):
+		return 0
+	case hasName(t.Damage_Relations.Half_Damage_From, attacking):
+		return 0.5
+	case hasName(t.Damage_Relations.Double_Damage_From, attacking):
+		return 2
+	}
+	return 1
+}
+
+// Multiplier for a move of the attacking type hitting a pokemon with the
+// defending types. Dual types multiply, so a move can be x4 or x0.25.
+func Effectiveness(attacking string, defending []Type) float64 {
+	multiplier := 1.0
+	for _, t := range defending {
+		multiplier *= t.DamageFrom(attacking)
+	}
+	return multiplier
+}
+
+// Multipliers for every battle type attacking the defending types, leaving
+// out the ones that do normal damage.
+func DefensiveChart(defending []Type) map[string]float64 {
+	chart := make(map[string]float64)
+	for _, attacking := range BattleTypes {
+		if m := Effectiveness(attacking, defending); m != 1 {
+			chart[attacking] = m
+		}
+	}
+	return chart
+}
+
+func hasName(resources []NamedAPIResource, name string) bool {
+	return slices.ContainsFunc(resources, func(r NamedAPIResource) bool {
+		return r.Name == name
+	})
+}
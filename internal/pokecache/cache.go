@@ -1,6 +1,7 @@
 package pokecache
 
 import (
+	"container/list"
 	"sync"
 	"time"
 )
@@ -8,15 +9,36 @@ import (
 //Caching of previous results so we don't query API repeatedly
 // for same result set.
 
+// elem is this entry's position in the LRU list. It is nil for entries
+// put straight into the Entries map rather than through Add.
 type cacheEntry struct {
-	createdAt time.Time
-	val       []byte
+	createdAt  time.Time
+	val        []byte
+	validators Validators
+	elem       *list.Element
 }
 
 // Notice mutex to protect the Entries map.
+// Disk is optional - if set, Get falls through to it on a miss and Add
+// writes through to it. Disk is never touched while holding CacheMutex, so
+// memory lookups don't wait on file I/O.
+// MaxEntries and MaxBytes bound the in-memory size (0 means no limit) - see lru.go.
 type Cache struct {
 	Entries    map[string]cacheEntry
 	CacheMutex sync.Mutex
+	Disk       *DiskStore
+	MaxEntries int
+	MaxBytes   int
+
+	// Most recently used key at the front
+	lru   *list.List
+	bytes int
+	stats Stats
+
+	// Closed by Close to stop reapLoop. closed is protected by CacheMutex.
+	done      chan struct{}
+	closeOnce sync.Once
+	closed    bool
 }
 
 // Pointer receiver as otherwise we will copy the struct and have a new mutex
@@ -25,22 +47,134 @@ type Cache struct {
 // which would allow multiple callers to simultaneously read/write to the map which is UB
 // Add an entry to the Entries map.
 func (c *Cache) Add(key string, val []byte) {
-	c.CacheMutex.Lock()
-	defer c.CacheMutex.Unlock()
+	c.AddWithValidators(key, val, Validators{})
+}
 
-	c.Entries[key] = cacheEntry{createdAt: time.Now(), val: val}
+// Add an entry along with the validators from the response it came from,
+// so it can be revalidated once it expires.
+func (c *Cache) AddWithValidators(key string, val []byte, v Validators) {
+	c.CacheMutex.Lock()
+	// Adds after Close are dropped
+	if c.closed {
+		c.CacheMutex.Unlock()
+		return
+	}
+	c.insert(key, val, v)
+	c.CacheMutex.Unlock()
+
+	// Written without holding the lock so other lookups don't wait on disk.
+	// Disk errors are ignored - the cache is best effort and the in-memory
+	// entry is still there.
+	if c.Disk != nil {
+		c.Disk.AddWithValidators(key, val, v)
+	}
 }
 
 func (c *Cache) Get(key string) ([]byte, bool) {
+	c.CacheMutex.Lock()
+	// A closed cache always misses
+	if c.closed {
+		c.CacheMutex.Unlock()
+		return []byte{}, false
+	}
+	entry, exists := c.Entries[key]
+	if exists {
+		c.touch(key, entry)
+		c.CacheMutex.Unlock()
+		return entry.val, true
+	}
+	c.CacheMutex.Unlock()
+
+	if c.Disk == nil {
+		return []byte{}, false
+	}
+
+	// Fall through to disk without holding the lock, so memory hits never
+	// wait on disk reads. Validators come along too in case this entry is
+	// revalidated later.
+	val, v, found := c.Disk.GetWithValidators(key)
+	if !found {
+		return []byte{}, false
+	}
+
+	// Promote the entry back into memory, unless something newer was added
+	// while we were reading or the cache was closed
 	c.CacheMutex.Lock()
 	defer c.CacheMutex.Unlock()
+	if _, exists := c.Entries[key]; !exists && !c.closed {
+		c.insert(key, val, v)
+	}
+	return val, true
+}
 
+// Get an entry that may have expired, along with its validators. Only entries
+// that have validators are returned. Memory entries are reaped once expired,
+// so in practice stale entries come from Disk.
+func (c *Cache) GetStale(key string) ([]byte, Validators, bool) {
+	c.CacheMutex.Lock()
+	if c.closed {
+		c.CacheMutex.Unlock()
+		return []byte{}, Validators{}, false
+	}
+	entry, exists := c.Entries[key]
+	c.CacheMutex.Unlock()
+
+	if exists && !entry.validators.Empty() {
+		return entry.val, entry.validators, true
+	}
+	if c.Disk != nil {
+		return c.Disk.GetStale(key)
+	}
+	return []byte{}, Validators{}, false
+}
+
+// Reset an entry's createdAt to now, i.e the API answered 304 Not Modified
+// so what we have is fresh again. The entry is promoted back into memory.
+func (c *Cache) Refresh(key string) {
+	c.CacheMutex.Lock()
+	if c.closed {
+		c.CacheMutex.Unlock()
+		return
+	}
 	entry, exists := c.Entries[key]
 	if exists {
-		return entry.val, true
+		c.insert(key, entry.val, entry.validators)
+	}
+	c.CacheMutex.Unlock()
+
+	if c.Disk == nil {
+		return
+	}
+
+	// As with Get, disk I/O happens without holding the lock
+	val, v := entry.val, entry.validators
+	if !exists {
+		var found bool
+		val, v, found = c.Disk.GetStale(key)
+		if !found {
+			return
+		}
+		c.CacheMutex.Lock()
+		if _, exists := c.Entries[key]; !exists && !c.closed {
+			c.insert(key, val, v)
+		}
+		c.CacheMutex.Unlock()
 	}
+	// Writing the entry again marks it fresh on disk too
+	c.Disk.AddWithValidators(key, val, v)
+}
+
+// Keys of the entries held in memory, in no particular order. Entries only on
+// Disk aren't included.
+func (c *Cache) Keys() []string {
+	c.CacheMutex.Lock()
+	defer c.CacheMutex.Unlock()
 
-	return []byte{}, false
+	keys := make([]string, 0, len(c.Entries))
+	for key := range c.Entries {
+		keys = append(keys, key)
+	}
+	return keys
 }
 
 func (c *Cache) reapLoop(interval time.Duration) {
@@ -50,8 +184,12 @@ func (c *Cache) reapLoop(interval time.Duration) {
 	//Wait for a tick from the channel - should be a tick every interval
 	// Once we stop blocks - we can loop again.
 	for {
-		// I am assuming we will block here until tick received and removed.
-		<-ticker.C
+		// Block until either a tick or Close is called
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
 
 		//Not using the tick time - interval - in case we were blocked.
 		// We want to collect anything that is more than interval old.
@@ -63,7 +201,8 @@ func (c *Cache) reapLoop(interval time.Duration) {
 		//Loop through map, delete any k:v where val.createdAt is after cutoffTime
 		for key, val := range c.Entries {
 			if val.createdAt.Before(cutoffTime) {
-				delete(c.Entries, key)
+				c.remove(key)
+				c.stats.Expired++
 			}
 		}
 		//Drop lock
@@ -76,9 +215,39 @@ func NewCache(interval time.Duration) *Cache {
 	cache := &Cache{
 		//need to initialise a map - rest fine as zeroinit
 		Entries: make(map[string]cacheEntry),
+		done:    make(chan struct{}),
 	}
 	//Calls looping method that deletes any entries older than
 	// the interval every interval tick using time.Ticker
 	go cache.reapLoop(interval)
 	return cache
 }
+
+// Same as NewCache but backed by a DiskStore, so entries reaped from
+// memory can still be read back from disk until the disk TTL runs out.
+func NewCacheWithDisk(interval time.Duration, disk *DiskStore) *Cache {
+	cache := NewCache(interval)
+	cache.Disk = disk
+	return cache
+}
+
+// Stop the reapLoop goroutine and empty the in-memory entries. After Close,
+// Add does nothing and Get always misses - entries already written to Disk
+// are kept for the next session. Safe to call more than once.
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		// done is nil if the Cache was built without NewCache, in which
+		// case there is no reapLoop to stop.
+		if c.done != nil {
+			close(c.done)
+		}
+
+		c.CacheMutex.Lock()
+		defer c.CacheMutex.Unlock()
+		c.closed = true
+		c.Entries = make(map[string]cacheEntry)
+		c.lru = nil
+		c.bytes = 0
+	})
+	return nil
+}
@@ -2,6 +2,9 @@ package pokecache
 
 import (
 	"bytes"
+	"fmt"
+	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -83,6 +86,7 @@ func TestCacheGet(t *testing.T) {
 
 func TestCacheReapLoop(t *testing.T) {
 	cache := NewCache(1 * time.Millisecond)
+	defer cache.Close()
 
 	cache.Add("one", []byte{32})
 	//Allow reapLoop to tick even if delayed slightly
@@ -98,3 +102,210 @@ func TestCacheReapLoop(t *testing.T) {
 	}
 
 }
+
+func TestDiskStore(t *testing.T) {
+	cases := []struct {
+		name          string
+		ttl           time.Duration
+		fileContents  []byte
+		expectedVal   []byte
+		expectedExist bool
+	}{
+		{
+			name:          "round trip",
+			ttl:           time.Hour,
+			expectedVal:   []byte("hello"),
+			expectedExist: true,
+		},
+		{
+			name:          "expired",
+			ttl:           time.Nanosecond,
+			expectedVal:   []byte{},
+			expectedExist: false,
+		},
+		{
+			name:          "corrupt file",
+			ttl:           time.Hour,
+			fileContents:  []byte(`{"key": "test", "val": `),
+			expectedVal:   []byte{},
+			expectedExist: false,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			disk, err := NewDiskStore(t.TempDir(), tt.ttl)
+			if err != nil {
+				t.Fatalf("could not create disk store: %v", err)
+			}
+
+			if err := disk.Add("test", []byte("hello")); err != nil {
+				t.Fatalf("could not add to disk store: %v", err)
+			}
+			// Simulate a half-written or otherwise broken file
+			if tt.fileContents != nil {
+				if err := os.WriteFile(disk.path("test"), tt.fileContents, 0o644); err != nil {
+					t.Fatalf("could not overwrite cache file: %v", err)
+				}
+			}
+			time.Sleep(time.Millisecond)
+
+			val, exist := disk.Get("test")
+			if !bytes.Equal(val, tt.expectedVal) {
+				t.Errorf("Expected to Get value %v, got %v", tt.expectedVal, val)
+			}
+			if exist != tt.expectedExist {
+				t.Errorf("Expected existence of key to be %v, but got: %v", tt.expectedExist, exist)
+			}
+
+			// Bad entries should be cleaned up rather than left lying around
+			if !tt.expectedExist {
+				if _, err := os.Stat(disk.path("test")); !os.IsNotExist(err) {
+					t.Errorf("Expected bad cache file to be removed, stat returned: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheDiskFallthrough(t *testing.T) {
+	dir := t.TempDir()
+	disk, err := NewDiskStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("could not create disk store: %v", err)
+	}
+
+	// Add writes through to disk
+	first := &Cache{Entries: make(map[string]cacheEntry), Disk: disk}
+	first.AddWithValidators("one", []byte("pikachu"), Validators{ETag: `"v1"`})
+
+	// A fresh cache (i.e a restart) should find the entry on disk
+	// and promote it into memory.
+	disk, err = NewDiskStore(dir, time.Hour)
+	if err != nil {
+		t.Fatalf("could not reopen disk store: %v", err)
+	}
+	second := &Cache{Entries: make(map[string]cacheEntry), Disk: disk}
+	val, exist := second.Get("one")
+	if !exist || !bytes.Equal(val, []byte("pikachu")) {
+		t.Errorf("Expected to read back pikachu from disk, got %v (exists: %v)", val, exist)
+	}
+	entry, inMemory := second.Entries["one"]
+	if !inMemory {
+		t.Errorf("Expected disk hit to be promoted into memory")
+	}
+	// Validators come back from the same read
+	if entry.validators.ETag != `"v1"` {
+		t.Errorf("Expected the promoted entry to keep its ETag, got: %q", entry.validators.ETag)
+	}
+
+	// Memory and disk lookups at the same time - run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := fmt.Sprint("key-", i%2)
+			second.Add(key, []byte("raichu"))
+			second.Get(key)
+			second.Get("one")
+			second.Keys()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	cases := []struct {
+		name            string
+		maxEntries      int
+		maxBytes        int
+		expectedKeys    []string
+		expectedEvicted []string
+		expectedByCount uint64
+		expectedBySize  uint64
+	}{
+		{
+			name:            "entry limit",
+			maxEntries:      2,
+			expectedKeys:    []string{"a", "c"},
+			expectedEvicted: []string{"b"},
+			expectedByCount: 1,
+		},
+		{
+			// each entry is 1 byte key + 4 byte val
+			name:            "byte limit",
+			maxBytes:        10,
+			expectedKeys:    []string{"a", "c"},
+			expectedEvicted: []string{"b"},
+			expectedBySize:  1,
+		},
+		{
+			name:            "no limits",
+			expectedKeys:    []string{"a", "b", "c"},
+			expectedEvicted: []string{},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &Cache{Entries: make(map[string]cacheEntry)}
+			cache.SetLimits(tt.maxEntries, tt.maxBytes)
+
+			cache.Add("a", []byte("aaaa"))
+			cache.Add("b", []byte("bbbb"))
+			// Use a so that b is now the least recently used
+			cache.Get("a")
+			cache.Add("c", []byte("cccc"))
+
+			for _, key := range tt.expectedKeys {
+				if _, exist := cache.Entries[key]; !exist {
+					t.Errorf("Expected key %v to still be cached", key)
+				}
+			}
+			for _, key := range tt.expectedEvicted {
+				if _, exist := cache.Entries[key]; exist {
+					t.Errorf("Expected key %v to be evicted", key)
+				}
+			}
+
+			stats := cache.Stats()
+			if stats.EvictedByCount != tt.expectedByCount || stats.EvictedBySize != tt.expectedBySize {
+				t.Errorf("Expected evictions by count/size %v/%v, got %v/%v",
+					tt.expectedByCount, tt.expectedBySize, stats.EvictedByCount, stats.EvictedBySize)
+			}
+			if stats.Entries != len(tt.expectedKeys) || stats.Bytes != 5*len(tt.expectedKeys) {
+				t.Errorf("Expected %v entries of 5 bytes, got %v entries totalling %v bytes",
+					len(tt.expectedKeys), stats.Entries, stats.Bytes)
+			}
+		})
+	}
+}
+
+func TestCacheClose(t *testing.T) {
+	cache := NewCache(1 * time.Millisecond)
+	cache.Add("one", []byte{32})
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Expected Close to succeed, got: %v", err)
+	}
+	// Closing twice should be harmless
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Expected second Close to succeed, got: %v", err)
+	}
+
+	if _, exist := cache.Get("one"); exist {
+		t.Errorf("Expected Get to miss after Close")
+	}
+	cache.Add("two", []byte{32})
+	if _, exist := cache.Get("two"); exist {
+		t.Errorf("Expected Add to be dropped after Close")
+	}
+
+	// reapLoop should have returned - done is closed so this can't block
+	select {
+	case <-cache.done:
+	default:
+		t.Errorf("Expected done channel to be closed")
+	}
+}
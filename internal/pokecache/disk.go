@@ -0,0 +1,182 @@
+package pokecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/atomicfile"
+)
+
+// Optional on-disk tier for the Cache. Entries written here survive restarts
+// of the REPL, so we don't re-download the same JSON every session.
+// Each entry is stored as its own JSON file named after a hash of the key.
+
+// diskEntry is what we actually write to each file. We keep the key inside
+// the file so we can double check we read back the entry we asked for.
+type diskEntry struct {
+	Key          string    `json:"key"`
+	CreatedAt    time.Time `json:"created_at"`
+	Val          []byte    `json:"val"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+func (e diskEntry) validators() Validators {
+	return Validators{ETag: e.ETag, LastModified: e.LastModified}
+}
+
+// Entries older than TTL are expired. Expired entries that have validators
+// are kept for a further MaxStale so they can be revalidated with the API
+// rather than downloaded again (0 keeps them indefinitely).
+type DiskStore struct {
+	Dir      string
+	TTL      time.Duration
+	MaxStale time.Duration
+}
+
+// Returns the default cache directory. os.UserCacheDir honours $XDG_CACHE_HOME
+// and falls back to ~/.cache on linux.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user cache directory: %v", err)
+	}
+	return filepath.Join(dir, "pokedexcli"), nil
+}
+
+// Create the cache directory if needed and return a DiskStore using it.
+// Anything already in the directory that has expired or is unreadable is
+// cleaned up here.
+func NewDiskStore(dir string, ttl time.Duration) (*DiskStore, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cache directory %v: %v", dir, err)
+	}
+	d := &DiskStore{Dir: dir, TTL: ttl, MaxStale: 30 * 24 * time.Hour}
+	d.Prune()
+	return d, nil
+}
+
+// Filenames are the sha256 of the key as keys are full URLs with
+// slashes and query strings that aren't safe to use as paths.
+func (d *DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Read an unexpired entry from disk. Corrupt or mismatched files are removed
+// and reported as a miss.
+func (d *DiskStore) Get(key string) ([]byte, bool) {
+	val, _, ok := d.GetWithValidators(key)
+	return val, ok
+}
+
+// As Get, also returning the entry's validators (which may be empty)
+func (d *DiskStore) GetWithValidators(key string) ([]byte, Validators, bool) {
+	entry, ok := d.lookup(key)
+	if !ok || d.expired(entry) {
+		return []byte{}, Validators{}, false
+	}
+	return entry.Val, entry.validators(), true
+}
+
+// Read an entry regardless of age, as long as it has validators we can use
+// to revalidate it.
+func (d *DiskStore) GetStale(key string) ([]byte, Validators, bool) {
+	entry, ok := d.lookup(key)
+	if !ok || entry.validators().Empty() {
+		return []byte{}, Validators{}, false
+	}
+	return entry.Val, entry.validators(), true
+}
+
+func (d *DiskStore) Add(key string, val []byte) error {
+	return d.write(key, val, Validators{})
+}
+
+func (d *DiskStore) AddWithValidators(key string, val []byte, v Validators) error {
+	return d.write(key, val, v)
+}
+
+func (d *DiskStore) lookup(key string) (diskEntry, bool) {
+	entry, ok := d.read(d.path(key))
+	if !ok || entry.Key != key {
+		return diskEntry{}, false
+	}
+	return entry, true
+}
+
+func (d *DiskStore) expired(entry diskEntry) bool {
+	return d.TTL > 0 && time.Since(entry.CreatedAt) > d.TTL
+}
+
+// Write an entry to disk. Written atomically so a crash mid-write leaves at
+// worst a stray temp file and never a half-written entry.
+func (d *DiskStore) write(key string, val []byte, v Validators) error {
+	data, err := json.Marshal(diskEntry{
+		Key:          key,
+		CreatedAt:    time.Now(),
+		Val:          val,
+		ETag:         v.ETag,
+		LastModified: v.LastModified,
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry: %v", err)
+	}
+	return atomicfile.WriteFile(d.path(key), data, 0o644)
+}
+
+// Remove corrupt entries, expired entries we can't revalidate, and any temp
+// files left behind by an interrupted write.
+func (d *DiskStore) Prune() {
+	files, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		path := filepath.Join(d.Dir, f.Name())
+		if strings.HasPrefix(f.Name(), "tmp-") {
+			os.Remove(path)
+			continue
+		}
+		if filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		// read removes the file itself if it is bad or expired
+		d.read(path)
+	}
+}
+
+func (d *DiskStore) read(path string) (diskEntry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return diskEntry{}, false
+	}
+
+	var entry diskEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		// Corrupt or truncated - no use to anyone so get rid of it.
+		os.Remove(path)
+		return diskEntry{}, false
+	}
+
+	// Expired entries are only worth keeping if we can revalidate them
+	if d.expired(entry) {
+		tooOld := d.MaxStale > 0 && time.Since(entry.CreatedAt) > d.TTL+d.MaxStale
+		if entry.validators().Empty() || tooOld {
+			os.Remove(path)
+			return diskEntry{}, false
+		}
+	}
+	return entry, true
+}
@@ -0,0 +1,119 @@
+package pokecache
+
+import (
+	"container/list"
+	"time"
+)
+
+// Size limits for the in-memory cache. reapLoop only removes entries by age,
+// so a long crawl of the API could otherwise grow the Entries map forever.
+// When either limit is hit we evict the least recently used entries first.
+
+// Counters so we can see how the limits are behaving and tune them.
+type Stats struct {
+	Entries        int
+	Bytes          int
+	EvictedByCount uint64
+	EvictedBySize  uint64
+	Expired        uint64
+}
+
+// Set the maximum number of entries and total bytes held in memory.
+// 0 means no limit. Anything over the new limits is evicted straight away.
+func (c *Cache) SetLimits(maxEntries, maxBytes int) {
+	c.CacheMutex.Lock()
+	defer c.CacheMutex.Unlock()
+
+	c.MaxEntries = maxEntries
+	c.MaxBytes = maxBytes
+	c.evict()
+}
+
+func (c *Cache) Stats() Stats {
+	c.CacheMutex.Lock()
+	defer c.CacheMutex.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.Entries)
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// We count the key as well as the value as both are held in memory.
+func entrySize(key string, val []byte) int {
+	return len(key) + len(val)
+}
+
+// Add or replace an entry and mark it most recently used.
+// Caller must hold CacheMutex.
+func (c *Cache) insert(key string, val []byte, v Validators) {
+	if c.lru == nil {
+		c.lru = list.New()
+	}
+	c.remove(key)
+
+	// An entry bigger than the whole budget would just evict everything
+	// else and then itself, so don't hold it in memory at all.
+	if c.MaxBytes > 0 && entrySize(key, val) > c.MaxBytes {
+		c.stats.EvictedBySize++
+		return
+	}
+
+	c.Entries[key] = cacheEntry{createdAt: time.Now(), val: val, validators: v, elem: c.lru.PushFront(key)}
+	c.bytes += entrySize(key, val)
+	c.evict()
+}
+
+// Mark an existing entry as most recently used. Caller must hold CacheMutex.
+func (c *Cache) touch(key string, entry cacheEntry) {
+	if c.lru == nil {
+		c.lru = list.New()
+	}
+	if entry.elem == nil {
+		// Entry was put in the map directly, start tracking it now
+		entry.elem = c.lru.PushFront(key)
+		c.Entries[key] = entry
+		c.bytes += entrySize(key, entry.val)
+		return
+	}
+	c.lru.MoveToFront(entry.elem)
+}
+
+// Delete an entry and its LRU bookkeeping. Caller must hold CacheMutex.
+func (c *Cache) remove(key string) {
+	entry, exists := c.Entries[key]
+	if !exists {
+		return
+	}
+	if entry.elem != nil {
+		c.lru.Remove(entry.elem)
+		c.bytes -= entrySize(key, entry.val)
+	}
+	delete(c.Entries, key)
+}
+
+// Drop least recently used entries until we are within both limits.
+// Caller must hold CacheMutex.
+func (c *Cache) evict() {
+	if c.lru == nil {
+		return
+	}
+	for {
+		overCount := c.MaxEntries > 0 && len(c.Entries) > c.MaxEntries
+		overSize := c.MaxBytes > 0 && c.bytes > c.MaxBytes
+		if !overCount && !overSize {
+			return
+		}
+
+		oldest := c.lru.Back()
+		if oldest == nil {
+			return
+		}
+		c.remove(oldest.Value.(string))
+		if overCount {
+			c.stats.EvictedByCount++
+		} else {
+			c.stats.EvictedBySize++
+		}
+	}
+}
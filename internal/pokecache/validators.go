@@ -0,0 +1,13 @@
+package pokecache
+
+// HTTP validators from the response an entry was cached from. Once an entry
+// has expired these let the caller ask the API whether it has changed
+// (If-None-Match / If-Modified-Since) instead of downloading it again.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+func (v Validators) Empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
@@ -0,0 +1,157 @@
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/atomicfile"
+)
+
+// Trainer profiles so people sharing a machine each get their own Pokedex,
+// map position and settings. Each profile is a directory under Manager.Dir
+// holding its save file and an optional settings file:
+//
+//	profiles/
+//	  current          <- name of the profile in use
+//	  misty/pokedex.json
+//	  misty/config.json
+//
+// The default profile keeps using the original save file location so saves
+// from before profiles existed still load.
+
+const DefaultProfile = "default"
+
+// Profile names become directory names, so keep them simple.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+type Manager struct {
+	Dir string
+	// Save file used by the default profile
+	DefaultSaveFile string
+	// Name of the profile in use
+	Current string
+}
+
+// Create a Manager for the profiles in dir, picking up the profile that was
+// in use last time. Falls back to the default profile if that one is gone.
+func NewManager(dir, defaultSaveFile string) (*Manager, error) {
+	m := &Manager{Dir: dir, DefaultSaveFile: defaultSaveFile, Current: DefaultProfile}
+
+	data, err := os.ReadFile(m.currentFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read current profile: %v", err)
+	}
+
+	name := strings.TrimSpace(string(data))
+	if m.Exists(name) {
+		m.Current = name
+	}
+	return m, nil
+}
+
+func (m *Manager) currentFile() string {
+	return filepath.Join(m.Dir, "current")
+}
+
+func (m *Manager) profileDir(name string) string {
+	return filepath.Join(m.Dir, name)
+}
+
+// Where a profile's Pokedex is saved.
+func (m *Manager) SaveFile(name string) string {
+	if name == DefaultProfile {
+		return m.DefaultSaveFile
+	}
+	return filepath.Join(m.profileDir(name), "pokedex.json")
+}
+
+// Where a profile's settings are kept. Settings here override the main
+// settings file while the profile is in use.
+func (m *Manager) SettingsFile(name string) string {
+	return filepath.Join(m.profileDir(name), "config.json")
+}
+
+func (m *Manager) Exists(name string) bool {
+	if name == DefaultProfile {
+		return true
+	}
+	if !validName.MatchString(name) {
+		return false
+	}
+	info, err := os.Stat(m.profileDir(name))
+	return err == nil && info.IsDir()
+}
+
+// All profile names, sorted, always including the default profile.
+func (m *Manager) List() ([]string, error) {
+	names := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(m.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return names, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not list profiles: %v", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != DefaultProfile && validName.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names[1:])
+	return names, nil
+}
+
+func (m *Manager) Create(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use up to 32 lowercase letters, digits, - or _", name)
+	}
+	if m.Exists(name) {
+		return fmt.Errorf("profile %v already exists", name)
+	}
+	err := os.MkdirAll(m.profileDir(name), 0o755)
+	if err != nil {
+		return fmt.Errorf("could not create profile %v: %v", name, err)
+	}
+	return nil
+}
+
+// Delete a profile and everything saved in it. The default profile and the
+// profile in use can't be deleted.
+func (m *Manager) Delete(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("the default profile can't be deleted")
+	}
+	if name == m.Current {
+		return fmt.Errorf("can't delete %v while it is in use - switch profile first", name)
+	}
+	if !m.Exists(name) {
+		return fmt.Errorf("no profile called %v", name)
+	}
+	err := os.RemoveAll(m.profileDir(name))
+	if err != nil {
+		return fmt.Errorf("could not delete profile %v: %v", name, err)
+	}
+	return nil
+}
+
+// Mark name as the profile in use, remembered for next time.
+func (m *Manager) SetCurrent(name string) error {
+	if !m.Exists(name) {
+		return fmt.Errorf("no profile called %v", name)
+	}
+	err := atomicfile.WriteFile(m.currentFile(), []byte(name+"\n"), 0o644)
+	if err != nil {
+		return fmt.Errorf("could not save current profile: %v", err)
+	}
+	m.Current = name
+	return nil
+}
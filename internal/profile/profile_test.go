@@ -0,0 +1,73 @@
+package profile
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	dir := t.TempDir()
+	defaultSave := filepath.Join(dir, "pokedex.json")
+	m, err := NewManager(filepath.Join(dir, "profiles"), defaultSave)
+	if err != nil {
+		t.Fatalf("NewManager returned error: %v", err)
+	}
+	if m.Current != DefaultProfile {
+		t.Errorf("Expected to start on the default profile, got: %v", m.Current)
+	}
+
+	// Create a couple of profiles, and check bad names and duplicates fail
+	for _, name := range []string{"misty", "brock"} {
+		if err := m.Create(name); err != nil {
+			t.Fatalf("Create(%v) returned error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"misty", "default", "../etc", "Ash", ""} {
+		if err := m.Create(name); err == nil {
+			t.Errorf("Expected Create(%q) to fail", name)
+		}
+	}
+
+	names, err := m.List()
+	if err != nil {
+		t.Fatalf("List returned error: %v", err)
+	}
+	expected := []string{"default", "brock", "misty"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected profiles %v, got: %v", expected, names)
+	}
+
+	if m.SaveFile(DefaultProfile) != defaultSave {
+		t.Errorf("Expected default profile to use %v, got: %v", defaultSave, m.SaveFile(DefaultProfile))
+	}
+	if m.SaveFile("misty") == m.SaveFile("brock") {
+		t.Errorf("Expected each profile to have its own save file")
+	}
+
+	// Current profile is remembered by a new Manager
+	if err := m.SetCurrent("misty"); err != nil {
+		t.Fatalf("SetCurrent returned error: %v", err)
+	}
+	reopened, err := NewManager(m.Dir, defaultSave)
+	if err != nil {
+		t.Fatalf("NewManager returned error: %v", err)
+	}
+	if reopened.Current != "misty" {
+		t.Errorf("Expected current profile misty to be remembered, got: %v", reopened.Current)
+	}
+
+	// Can't delete the profile in use or the default one
+	if err := m.Delete("misty"); err == nil {
+		t.Errorf("Expected deleting the current profile to fail")
+	}
+	if err := m.Delete(DefaultProfile); err == nil {
+		t.Errorf("Expected deleting the default profile to fail")
+	}
+	if err := m.Delete("brock"); err != nil {
+		t.Errorf("Delete(brock) returned error: %v", err)
+	}
+	if m.Exists("brock") {
+		t.Errorf("Expected brock to be gone after Delete")
+	}
+}
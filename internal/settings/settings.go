@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
+)
+
+// User settings for the CLI. These come from (lowest to highest priority):
+// the settings file, the trainer profile's settings file, environment
+// variables, then command line flags. See Merge.
+
+const (
+	// Environment variable to override the API base URL
+	BaseURLEnv = "POKEAPI_BASE_URL"
+	// Environment variable to set the output format, e.g json for scripts
+	OutputEnv = "POKEDEX_OUTPUT"
+)
+
+// MaxAttempts is the number of tries for each API request, each waiting up to
+// Timeout seconds for a response. RateLimit is in
+// requests per second with bursts of up to RateBurst. CacheMaxEntries and
+// CacheMaxBytes limit what the in-memory cache holds. 0 uses the default for each.
+type Settings struct {
+	BaseURL         string  `json:"base_url,omitempty"`
+	MaxAttempts     int     `json:"max_attempts,omitempty"`
+	Timeout         float64 `json:"timeout,omitempty"`
+	RateLimit       float64 `json:"rate_limit,omitempty"`
+	RateBurst       int     `json:"rate_burst,omitempty"`
+	CacheMaxEntries int     `json:"cache_max_entries,omitempty"`
+	CacheMaxBytes   int     `json:"cache_max_bytes,omitempty"`
+	Verbose         bool    `json:"verbose,omitempty"`
+	// Where the default profile's Pokedex is saved. Empty uses pokedex.json in DataDir.
+	SaveFile string `json:"save_file,omitempty"`
+	// How command results are printed: text, json, yaml or table. Empty means text.
+	Output string `json:"output,omitempty"`
+}
+
+// Default settings file location. os.UserConfigDir honours $XDG_CONFIG_HOME
+// and falls back to ~/.config on linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user config directory: %v", err)
+	}
+	return filepath.Join(dir, "pokedexcli", "config.json"), nil
+}
+
+// Directory for data we want to keep, like the saved Pokedex. Go has no
+// os.UserDataDir so we follow the XDG spec ourselves: $XDG_DATA_HOME or
+// ~/.local/share.
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "pokedexcli"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find user data directory: %v", err)
+	}
+	return filepath.Join(home, ".local", "share", "pokedexcli"), nil
+}
+
+// Read settings from a JSON file. A missing file is not an error - it just
+// means everything is left at its default.
+func Load(path string) (Settings, error) {
+	var s Settings
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("could not read settings file %v: %v", path, err)
+	}
+
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return s, fmt.Errorf("could not parse settings file %v: %v", path, err)
+	}
+	return s, nil
+}
+
+// Override settings with any that are set in the environment.
+// getenv is passed in (normally os.Getenv) so tests don't need to touch the
+// real environment.
+func (s *Settings) ApplyEnv(getenv func(string) string) {
+	if v := getenv(BaseURLEnv); v != "" {
+		s.BaseURL = v
+	}
+	if v := getenv(OutputEnv); v != "" {
+		s.Output = v
+	}
+}
+
+// Override s with every field that is set (non zero) in other. Used to layer
+// settings from different sources on top of each other.
+func (s *Settings) Merge(other Settings) {
+	if other.BaseURL != "" {
+		s.BaseURL = other.BaseURL
+	}
+	if other.MaxAttempts != 0 {
+		s.MaxAttempts = other.MaxAttempts
+	}
+	if other.Timeout != 0 {
+		s.Timeout = other.Timeout
+	}
+	if other.RateLimit != 0 {
+		s.RateLimit = other.RateLimit
+	}
+	if other.RateBurst != 0 {
+		s.RateBurst = other.RateBurst
+	}
+	if other.CacheMaxEntries != 0 {
+		s.CacheMaxEntries = other.CacheMaxEntries
+	}
+	if other.CacheMaxBytes != 0 {
+		s.CacheMaxBytes = other.CacheMaxBytes
+	}
+	if other.Verbose {
+		s.Verbose = true
+	}
+	if other.SaveFile != "" {
+		s.SaveFile = other.SaveFile
+	}
+	if other.Output != "" {
+		s.Output = other.Output
+	}
+}
+
+// Check the settings make sense before we use them.
+func (s *Settings) Validate() error {
+	if s.MaxAttempts < 0 {
+		return fmt.Errorf("invalid max attempts %v: must not be negative", s.MaxAttempts)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %v: must not be negative", s.Timeout)
+	}
+	if s.RateLimit < 0 || s.RateBurst < 0 {
+		return fmt.Errorf("invalid rate limit %v burst %v: must not be negative", s.RateLimit, s.RateBurst)
+	}
+	if s.CacheMaxEntries < 0 || s.CacheMaxBytes < 0 {
+		return fmt.Errorf("invalid cache limits %v entries %v bytes: must not be negative", s.CacheMaxEntries, s.CacheMaxBytes)
+	}
+	if _, err := output.ParseFormat(s.Output); err != nil {
+		return err
+	}
+	if s.BaseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(s.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL %v: %v", s.BaseURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid base URL %v: must be an absolute http(s) URL", s.BaseURL)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name            string
+		fileContents    string
+		writeFile       bool
+		expectedErr     bool
+		expectedBaseURL string
+	}{
+		{
+			name:            "missing file",
+			writeFile:       false,
+			expectedErr:     false,
+			expectedBaseURL: "",
+		},
+		{
+			name:            "base url set",
+			fileContents:    `{"base_url": "http://localhost:8000/api/v2"}`,
+			writeFile:       true,
+			expectedErr:     false,
+			expectedBaseURL: "http://localhost:8000/api/v2",
+		},
+		{
+			name:            "malformed file",
+			fileContents:    `{"base_url": `,
+			writeFile:       true,
+			expectedErr:     true,
+			expectedBaseURL: "",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.writeFile {
+				if err := os.WriteFile(path, []byte(tt.fileContents), 0o644); err != nil {
+					t.Fatalf("could not write settings file: %v", err)
+				}
+			}
+
+			s, err := Load(path)
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("Expected error: %v, got: %v", tt.expectedErr, err)
+			}
+			if s.BaseURL != tt.expectedBaseURL {
+				t.Errorf("Expected base url: %v, got: %v", tt.expectedBaseURL, s.BaseURL)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	s := Settings{BaseURL: "http://from-file/api/v2"}
+
+	// Unset env var leaves the file value alone
+	s.ApplyEnv(func(string) string { return "" })
+	if s.BaseURL != "http://from-file/api/v2" {
+		t.Errorf("Expected base url from file to be kept, got: %v", s.BaseURL)
+	}
+
+	s.ApplyEnv(func(key string) string {
+		if key == BaseURLEnv {
+			return "http://from-env/api/v2"
+		}
+		return ""
+	})
+	if s.BaseURL != "http://from-env/api/v2" {
+		t.Errorf("Expected base url from env, got: %v", s.BaseURL)
+	}
+
+	s.ApplyEnv(func(key string) string {
+		if key == OutputEnv {
+			return "json"
+		}
+		return ""
+	})
+	if s.Output != "json" {
+		t.Errorf("Expected output format from env, got: %v", s.Output)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		baseURL     string
+		maxAttempts int
+		rateLimit   float64
+		timeout     float64
+		cacheBytes  int
+		output      string
+		expectedErr bool
+	}{
+		{baseURL: "", expectedErr: false},
+		{baseURL: "", maxAttempts: -1, expectedErr: true},
+		{baseURL: "", rateLimit: -1, expectedErr: true},
+		{baseURL: "", timeout: -1, expectedErr: true},
+		{baseURL: "", cacheBytes: -1, expectedErr: true},
+		{baseURL: "https://pokeapi.co/api/v2", expectedErr: false},
+		{baseURL: "http://127.0.0.1:8080", expectedErr: false},
+		{baseURL: "pokeapi.co/api/v2", expectedErr: true},
+		{baseURL: "ftp://pokeapi.co", expectedErr: true},
+		{baseURL: "", output: "json", expectedErr: false},
+		{baseURL: "", output: "xml", expectedErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			s := Settings{BaseURL: tt.baseURL, MaxAttempts: tt.maxAttempts, RateLimit: tt.rateLimit, Timeout: tt.timeout, CacheMaxBytes: tt.cacheBytes, Output: tt.output}
+			err := s.Validate()
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("Expected error: %v, got: %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	s := Settings{BaseURL: "http://from-file/api/v2", MaxAttempts: 5, RateLimit: 2, CacheMaxEntries: 100}
+	s.Merge(Settings{BaseURL: "http://from-flag/api/v2", Timeout: 0.5, RateBurst: 7, CacheMaxBytes: 1 << 20, Verbose: true})
+
+	expected := Settings{BaseURL: "http://from-flag/api/v2", MaxAttempts: 5, Timeout: 0.5, RateLimit: 2, RateBurst: 7, CacheMaxEntries: 100, CacheMaxBytes: 1 << 20, Verbose: true}
+	if s != expected {
+		t.Errorf("Expected merged settings %+v, got: %+v", expected, s)
+	}
+}
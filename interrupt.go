@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// Ctrl-C should cancel the command that is running (and its requests to the
+// API) rather than kill the whole REPL. commandCanceller keeps hold of the
+// cancel func for the running command so the signal handler can call it.
+type commandCanceller struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// Get a context for the next command. Call finish once the command returns.
+func (c *commandCanceller) start() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+	return ctx
+}
+
+func (c *commandCanceller) finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+// Cancel the running command. Returns false if there wasn't one, i.e we
+// are sitting at the prompt.
+func (c *commandCanceller) interrupt() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel == nil {
+		return false
+	}
+	c.cancel()
+	c.cancel = nil
+	return true
+}
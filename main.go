@@ -1,5 +1,131 @@
 package main
 
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/alias"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+	"github.com/Fraegdegjevar/pokedexcli/internal/settings"
+)
+
 func main() {
-	startRepl()
+	settingsPath := flag.String("config", "", "path to the settings file (default $XDG_CONFIG_HOME/pokedexcli/config.json)")
+	profileName := flag.String("profile", "", "trainer profile to use (default the last one used)")
+	oneShot := flag.String("c", "", "run a single command, e.g -c \"catch pikachu\", then exit")
+	script := flag.String("script", "", "run the commands in a file, one per line, then exit (- reads stdin)")
+
+	// Flags go straight into their own Settings layer - unset flags are left
+	// at zero so they don't override anything.
+	var flags settings.Settings
+	flag.StringVar(&flags.BaseURL, "base-url", "", "PokeAPI base URL, e.g a self-hosted mirror (default "+pokeapi.DefaultBaseURL+", env "+settings.BaseURLEnv+")")
+	flag.IntVar(&flags.MaxAttempts, "max-attempts", 0, "number of tries for each API request, 1 disables retries (default 3)")
+	flag.Float64Var(&flags.Timeout, "timeout", 0, "seconds to wait for each API response (default 2)")
+	flag.Float64Var(&flags.RateLimit, "rate", 0, "maximum API requests per second (default 10)")
+	flag.IntVar(&flags.RateBurst, "burst", 0, "maximum burst of API requests above the rate (default 20)")
+	flag.IntVar(&flags.CacheMaxEntries, "cache-max-entries", 0, "maximum number of API responses kept in memory (default 500)")
+	flag.IntVar(&flags.CacheMaxBytes, "cache-max-bytes", 0, "maximum bytes of API responses kept in memory (default 32MiB)")
+	flag.StringVar(&flags.SaveFile, "save", "", "path to the default profile's Pokedex save file (default $XDG_DATA_HOME/pokedexcli/pokedex.json)")
+	flag.BoolVar(&flags.Verbose, "verbose", false, "print extra detail such as retried requests, and cache stats on exit")
+	flag.StringVar(&flags.Output, "output", "", "how to print results: text, json, yaml or table (default text, env "+settings.OutputEnv+")")
+	flag.Parse()
+
+	file, err := loadSettingsFile(*settingsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	var env settings.Settings
+	env.ApplyEnv(os.Getenv)
+
+	layers := settingsLayers{file: file, env: env, flags: flags}
+	s := layers.resolve(settings.Settings{})
+	if err := s.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+
+	// Anything left after the flags is a command to run, e.g
+	// pokedexcli explore pastoria-city-area
+	if (*oneShot != "" || flag.NArg() > 0) && *script != "" {
+		fmt.Fprintln(os.Stderr, "use either a command or -script, not both")
+		os.Exit(exitUsage)
+	}
+	if *oneShot != "" && flag.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "use either -c or a command after the flags, not both")
+		os.Exit(exitUsage)
+	}
+
+	session := newSession(layers, *profileName, loadAliases(*settingsPath))
+	switch {
+	case *oneShot != "":
+		os.Exit(runNonInteractive(session, strings.NewReader(*oneShot), "-c"))
+	case flag.NArg() > 0:
+		os.Exit(runNonInteractive(session, strings.NewReader(strings.Join(flag.Args(), " ")), "command line"))
+	case *script == "-":
+		os.Exit(runNonInteractive(session, os.Stdin, "stdin"))
+	case *script != "":
+		f, err := os.Open(*script)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			shutdown(session.Config)
+			os.Exit(exitUsage)
+		}
+		code := runNonInteractive(session, f, *script)
+		f.Close()
+		os.Exit(code)
+	}
+
+	os.Exit(startRepl(session))
+}
+
+// Settings from each source, kept apart so a trainer profile's settings can
+// be slotted in between when switching profile.
+type settingsLayers struct {
+	file  settings.Settings
+	env   settings.Settings
+	flags settings.Settings
+}
+
+// Combine the layers: settings file < profile < environment < flags
+func (l settingsLayers) resolve(profile settings.Settings) settings.Settings {
+	s := l.file
+	s.Merge(profile)
+	s.Merge(l.env)
+	s.Merge(l.flags)
+	return s
+}
+
+// Load the user's aliases from next to the settings file. If they can't be
+// read we carry on without them rather than risk saving over the file.
+func loadAliases(settingsPath string) *alias.Store {
+	if settingsPath == "" {
+		var err error
+		settingsPath, err = settings.DefaultPath()
+		if err != nil {
+			return nil
+		}
+	}
+	aliases, err := alias.Load(alias.DefaultPath(settingsPath))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Aliases disabled for this session.")
+		return nil
+	}
+	return aliases
+}
+
+// Load the settings file, from the default location if path is empty
+func loadSettingsFile(path string) (settings.Settings, error) {
+	if path == "" {
+		var err error
+		path, err = settings.DefaultPath()
+		if err != nil {
+			// No config dir - just use defaults
+			return settings.Settings{}, nil
+		}
+	}
+	return settings.Load(path)
 }
@@ -1,47 +1,360 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/Fraegdegjevar/pokedexcli/internal/alias"
 	"github.com/Fraegdegjevar/pokedexcli/internal/command"
+	"github.com/Fraegdegjevar/pokedexcli/internal/history"
+	"github.com/Fraegdegjevar/pokedexcli/internal/output"
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
 	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+	"github.com/Fraegdegjevar/pokedexcli/internal/profile"
+	"github.com/Fraegdegjevar/pokedexcli/internal/settings"
 )
 
-func startRepl() {
-	scanner := bufio.NewScanner(os.Stdin)
-	config := &pokeapi.Config{Cache: pokecache.NewCache(5 * time.Second),
-		Pokedex: make(map[string]pokeapi.Pokemon)}
+// Build the Env, and the Config inside it, shared by every command - used by
+// the REPL and by non-interactive runs alike. aliases may be nil.
+func newSession(layers settingsLayers, profileName string, aliases *alias.Store) *command.Env {
+	config := &pokeapi.Config{Pokedex: make(map[string]pokeapi.Pokemon)}
+
+	// Progress messages from the API layer go wherever commands' errors go
+	env := command.NewEnv(config)
+	env.Aliases = aliases
+	config.Log = env.Stderr
+
+	config.Cache = newCache(env.Stderr)
+	setupProfiles(config, layers, profileName, env.Stderr)
+	return env
+}
+
+// Run the interactive REPL until the user exits, stdin is closed or we get
+// SIGTERM. Returns the exit code for the process.
+func startRepl(env *command.Env) int {
+	// Catch Ctrl-C ourselves - it cancels the running command instead of
+	// exiting. SIGTERM shuts down cleanly - see runRepl.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
 	supportedCommands := command.GetSupportedCommands()
+	hist := loadHistory()
+	env.History = hist
+	reader := newLineReader(hist, func(line string) []string {
+		return command.Completions(env, supportedCommands, line)
+	})
+	return runRepl(env, reader, signals)
+}
+
+// The REPL loop, reading lines from reader and reacting to the signals
+// startRepl catches. Returns the exit code for the process once shut down.
+func runRepl(env *command.Env, reader lineReader, signals <-chan os.Signal) int {
+	supportedCommands := command.GetSupportedCommands()
+
+	// Ctrl-C at the prompt does nothing but remind the user how to quit.
+	// SIGTERM cancels the running command too, then shuts down cleanly. A
+	// second SIGTERM while we are shutting down doesn't wait any longer.
+	canceller := &commandCanceller{}
+	terminate := make(chan struct{})
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		terminating := false
+		for {
+			var sig os.Signal
+			select {
+			case sig = <-signals:
+			case <-finished:
+				return
+			}
+			switch {
+			case sig == syscall.SIGTERM && terminating:
+				fmt.Fprintln(env.Stderr, "Terminated again, exiting without waiting for shutdown.")
+				os.Exit(exitInterrupt)
+			case sig == syscall.SIGTERM:
+				terminating = true
+				canceller.interrupt()
+				close(terminate)
+			case !canceller.interrupt() && !terminating:
+				fmt.Fprint(env.Stdout, "\n(type exit to quit)\n"+prompt)
+			}
+		}
+	}()
 
+	defer reader.Close()
+
+	// Read lines on their own goroutine so waiting at the prompt doesn't stop
+	// us noticing SIGTERM. Only one line is read per request, so the line
+	// editor is never holding the terminal while a command runs.
+	type readResult struct {
+		line string
+		err  error
+	}
+	requests := make(chan struct{})
+	// Room for one result so a read that finishes after we stop listening
+	// doesn't leave the goroutine stuck
+	results := make(chan readResult, 1)
+	defer close(requests)
+	go func() {
+		for range requests {
+			line, err := reader.ReadLine()
+			results <- readResult{line, err}
+		}
+	}()
+
+	code := exitOK
+loop:
 	for {
-		//Notice lack of newline
-		fmt.Print("Pokedex > ")
+		var input string
+		requests <- struct{}{}
+		select {
+		case r := <-results:
+			if errors.Is(r.err, errPromptInterrupted) {
+				fmt.Fprintln(env.Stdout, "(type exit to quit)")
+				continue
+			}
+			// EOF (e.g Ctrl-D or the end of piped input) quits like exit
+			if r.err == io.EOF {
+				fmt.Fprintln(env.Stdout)
+				break loop
+			}
+			if r.err != nil {
+				fmt.Fprintf(env.Stderr, "\nerror reading input: %v\n", r.err)
+				code = exitFailure
+				break loop
+			}
+			input = r.line
+		case <-terminate:
+			fmt.Fprintln(env.Stdout)
+			break loop
+		}
 
-		//Progress scanner to text object or loop again if missing
-		if !scanner.Scan() {
+		//If blank input loop again
+		if strings.TrimSpace(input) == "" {
 			continue
 		}
-		//If blank input loop again
-		input := scanner.Text()
-		if input == "" {
+
+		// Swap !-references (e.g !12, !catch) for the line they refer to,
+		// showing what we're about to run like a shell does. The expanded
+		// line is what goes in the history.
+		input, isRef, err := env.History.Expand(input)
+		if err != nil {
+			fmt.Fprintln(env.Stdout, err)
 			continue
 		}
+		if isRef {
+			fmt.Fprintln(env.Stdout, input)
+		}
+		env.History.Add(input)
 		cleanedInput := cleanInput(input)
 
 		// Try to match command and call it
-		err := command.ExecuteCommand(supportedCommands, cleanedInput, config)
-		if err != nil {
-			fmt.Println(err)
+		ctx := canceller.start()
+		err = command.ExecuteCommand(ctx, supportedCommands, cleanedInput, env)
+		canceller.finish()
+		if errors.Is(err, command.ErrExit) {
+			break loop
+		}
+
+		// A SIGTERM mid-command cancels it - don't report that as an error
+		select {
+		case <-terminate:
+			break loop
+		default:
+		}
+
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(env.Stdout, "\nCommand cancelled.")
+		} else if err != nil {
+			fmt.Fprintln(env.Stdout, err)
+		}
+	}
+
+	// Put the terminal back before anything else is printed
+	reader.Close()
+	if err := shutdown(env.Config); err != nil {
+		fmt.Fprintln(env.Stderr, err)
+		code = exitFailure
+	}
+	return code
+}
+
+// Lines entered at the prompt are kept in the data directory, shared by all
+// profiles. If there's nowhere to keep them history only lasts the session.
+func loadHistory() *history.History {
+	path := ""
+	if dir, err := settings.DataDir(); err == nil {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "History won't be saved: %v\n", err)
+		} else {
+			path = filepath.Join(dir, "history")
 		}
 	}
+	hist, err := history.Load(path, history.DefaultMax)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return &history.History{Max: history.DefaultMax}
+	}
+	return hist
+}
+
+// Everything that must happen before we exit, however we got there - the exit
+// command, EOF, a signal or the end of a script. Saves the Pokedex and stops
+// the cache's reap goroutine. With -verbose the cache's stats are printed
+// first, to help tune its limits.
+func shutdown(config *pokeapi.Config) error {
+	if config.Verbose && config.Log != nil && config.Cache != nil {
+		s := config.Cache.Stats()
+		fmt.Fprintf(config.Log, "Cache: %v entries, %v bytes in memory. Evicted %v over the entry limit, %v over the byte limit, %v expired.\n",
+			s.Entries, s.Bytes, s.EvictedByCount, s.EvictedBySize, s.Expired)
+	}
+	return config.Close()
 }
 
 func cleanInput(text string) []string {
 	stringLower := strings.ToLower(text)
 	return strings.Fields(stringLower)
 }
+
+// Set up trainer profiles, then load the current profile's settings and
+// Pokedex. Without a data directory we run without profiles or saving.
+// Warnings go to stderr so they don't get mixed up with json/yaml output.
+func setupProfiles(config *pokeapi.Config, layers settingsLayers, profileName string, stderr io.Writer) {
+	base := layers.resolve(settings.Settings{})
+	applySettings(config, base)
+
+	dataDir, err := settings.DataDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "Profiles and saving disabled: %v\n", err)
+		return
+	}
+	defaultSave := base.SaveFile
+	if defaultSave == "" {
+		defaultSave = filepath.Join(dataDir, "pokedex.json")
+	}
+	profiles, err := profile.NewManager(filepath.Join(dataDir, "profiles"), defaultSave)
+	if err != nil {
+		fmt.Fprintf(stderr, "Profiles disabled: %v\n", err)
+		config.SaveFile = defaultSave
+		loadPokedex(config, stderr)
+		return
+	}
+	config.Profiles = profiles
+
+	// Each profile's settings sit between the settings file and env/flags
+	config.OnProfileSwitch = func(name string) error {
+		profileSettings, err := settings.Load(profiles.SettingsFile(name))
+		if err != nil {
+			return err
+		}
+		s := layers.resolve(profileSettings)
+		if err := s.Validate(); err != nil {
+			return err
+		}
+		applySettings(config, s)
+		return nil
+	}
+
+	if profileName != "" {
+		if err := profiles.SetCurrent(profileName); err != nil {
+			fmt.Fprintf(stderr, "%v - using profile %v\n", err, profiles.Current)
+		}
+	}
+	if err := config.OnProfileSwitch(profiles.Current); err != nil {
+		fmt.Fprintf(stderr, "Could not apply settings for profile %v: %v\n", profiles.Current, err)
+	}
+	config.SaveFile = profiles.SaveFile(profiles.Current)
+	loadPokedex(config, stderr)
+}
+
+// Default limits on the in-memory cache, so long crawls of the API don't
+// grow it forever
+const (
+	defaultCacheMaxEntries = 500
+	defaultCacheMaxBytes   = 32 << 20
+)
+
+// Apply settings that can change when switching profile
+func applySettings(config *pokeapi.Config, s settings.Settings) {
+	config.BaseURL = s.BaseURL
+	config.Client = newClient(s, config.Log)
+	config.Verbose = s.Verbose
+	if config.Cache != nil {
+		maxEntries, maxBytes := defaultCacheMaxEntries, defaultCacheMaxBytes
+		if s.CacheMaxEntries > 0 {
+			maxEntries = s.CacheMaxEntries
+		}
+		if s.CacheMaxBytes > 0 {
+			maxBytes = s.CacheMaxBytes
+		}
+		config.Cache.SetLimits(maxEntries, maxBytes)
+	}
+	// Already validated so the error can't happen
+	config.Output, _ = output.ParseFormat(s.Output)
+}
+
+// Load the saved Pokedex. If the save can't be read we carry on with an
+// empty Pokedex but don't save over the file, so nothing is lost. The
+// warning goes to stderr.
+func loadPokedex(config *pokeapi.Config, stderr io.Writer) {
+	err := config.LoadPokedex()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		fmt.Fprintln(stderr, "Saving disabled for this session so the existing save file is not overwritten.")
+		config.SaveFile = ""
+	}
+}
+
+// Build the API client from the user's settings. Verbose messages go to log.
+func newClient(s settings.Settings, log io.Writer) *pokeapi.Client {
+	timeout := pokeapi.DefaultTimeout
+	if s.Timeout > 0 {
+		timeout = time.Duration(s.Timeout * float64(time.Second))
+	}
+	client := pokeapi.NewClient(timeout)
+	if s.MaxAttempts > 0 {
+		client.Retry.MaxAttempts = s.MaxAttempts
+	}
+	if s.RateLimit > 0 || s.RateBurst > 0 {
+		rate, burst := pokeapi.DefaultRate, pokeapi.DefaultBurst
+		if s.RateLimit > 0 {
+			rate = s.RateLimit
+		}
+		if s.RateBurst > 0 {
+			burst = s.RateBurst
+		}
+		client.Limiter = pokeapi.NewRateLimiter(rate, burst)
+	}
+	if s.Verbose && log != nil {
+		client.Logf = func(format string, args ...any) {
+			fmt.Fprintf(log, format, args...)
+		}
+	}
+	return client
+}
+
+// Build the cache - in memory entries are backed by a disk cache under the
+// user's cache directory. If we can't set up the disk cache we carry on
+// with memory only, and say why on stderr.
+func newCache(stderr io.Writer) *pokecache.Cache {
+	dir, err := pokecache.DefaultCacheDir()
+	if err != nil {
+		fmt.Fprintf(stderr, "Disk cache disabled: %v\n", err)
+		return pokecache.NewCache(5 * time.Second)
+	}
+	disk, err := pokecache.NewDiskStore(dir, 24*time.Hour)
+	if err != nil {
+		fmt.Fprintf(stderr, "Disk cache disabled: %v\n", err)
+		return pokecache.NewCache(5 * time.Second)
+	}
+	return pokecache.NewCacheWithDisk(5*time.Second, disk)
+}
@@ -1,7 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/command"
+	"github.com/Fraegdegjevar/pokedexcli/internal/history"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokeapi"
+	"github.com/Fraegdegjevar/pokedexcli/internal/pokecache"
+	"github.com/Fraegdegjevar/pokedexcli/internal/settings"
 )
 
 func TestCleanInput(t *testing.T) {
@@ -47,3 +62,355 @@ func TestCleanInput(t *testing.T) {
 	}
 
 }
+
+func TestCommandCanceller(t *testing.T) {
+	canceller := &commandCanceller{}
+
+	// Nothing running yet - interrupt should report that
+	if canceller.interrupt() {
+		t.Errorf("Expected interrupt at the prompt to return false")
+	}
+
+	ctx := canceller.start()
+	if !canceller.interrupt() {
+		t.Errorf("Expected interrupt during a command to return true")
+	}
+	if ctx.Err() == nil {
+		t.Errorf("Expected command context to be cancelled by interrupt")
+	}
+	canceller.finish()
+
+	// A new command gets a fresh, uncancelled context
+	ctx = canceller.start()
+	if ctx.Err() != nil {
+		t.Errorf("Expected new command context not to be cancelled, got: %v", ctx.Err())
+	}
+	canceller.finish()
+	if ctx.Err() == nil {
+		t.Errorf("Expected finish to release the command context")
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	// Only commands that don't need the API so this runs offline
+	cases := []struct {
+		name     string
+		script   string
+		expected int
+		errOut   string
+	}{
+		{
+			name:     "comments and blank lines are skipped",
+			script:   "# a comment\n\n   \npokedex\n",
+			expected: exitOK,
+		},
+		{
+			name:     "unknown command fails with its line number",
+			script:   "pokedex\nnotacommand\npokedex\n",
+			expected: exitFailure,
+			errOut:   "test:2: unknown command: notacommand",
+		},
+		{
+			name:     "failing command stops the script",
+			script:   "inspect\nnotacommand\n",
+			expected: exitFailure,
+			errOut:   "test:1:",
+		},
+		{
+			name:     "uncaught pokemon fails",
+			script:   "inspect pikachu\n",
+			expected: exitFailure,
+			errOut:   "test:1:",
+		},
+		{
+			name:     "exit stops the script successfully",
+			script:   "pokedex\nexit\nnotacommand\n",
+			expected: exitOK,
+		},
+		{
+			name:     "empty script is fine",
+			script:   "",
+			expected: exitOK,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			env := &command.Env{
+				Stdout: &out,
+				Stderr: &errOut,
+				Stdin:  strings.NewReader(""),
+				Config: &pokeapi.Config{Pokedex: make(map[string]pokeapi.Pokemon)},
+			}
+			code := runCommands(context.Background(), env, strings.NewReader(c.script), "test")
+			if code != c.expected {
+				t.Errorf("expected exit code %v, got %v (stderr %q)", c.expected, code, errOut.String())
+			}
+			if !strings.Contains(errOut.String(), c.errOut) {
+				t.Errorf("expected stderr to contain %q, got %q", c.errOut, errOut.String())
+			}
+		})
+	}
+}
+
+// A lineReader giving lines and then EOF. If block is set, once out of lines
+// it waits to be closed instead, like a user sat at the prompt.
+type fakeReader struct {
+	lines     []string
+	block     bool
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newFakeReader(block bool, lines ...string) *fakeReader {
+	return &fakeReader{lines: lines, block: block, closed: make(chan struct{})}
+}
+
+func (r *fakeReader) ReadLine() (string, error) {
+	if len(r.lines) > 0 {
+		line := r.lines[0]
+		r.lines = r.lines[1:]
+		return line, nil
+	}
+	if r.block {
+		<-r.closed
+	}
+	return "", io.EOF
+}
+
+func (r *fakeReader) Close() error {
+	r.closeOnce.Do(func() { close(r.closed) })
+	return nil
+}
+
+func TestRunRepl(t *testing.T) {
+	cases := []struct {
+		name     string
+		reader   *fakeReader
+		sigterm  bool
+		expected string
+		notOut   string
+	}{
+		{
+			name:     "EOF shuts down",
+			reader:   newFakeReader(false, "pokedex"),
+			expected: "Your Pokedex:\n  - pikachu\n",
+		},
+		{
+			name:   "exit stops reading",
+			reader: newFakeReader(false, "exit", "pokedex"),
+			notOut: "Your Pokedex:",
+		},
+		{
+			name:    "SIGTERM at the prompt shuts down",
+			reader:  newFakeReader(true),
+			sigterm: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			saveFile := filepath.Join(t.TempDir(), "pokedex.json")
+			env := &command.Env{
+				Stdout:  &out,
+				Stderr:  &errOut,
+				Stdin:   strings.NewReader(""),
+				History: &history.History{Max: history.DefaultMax},
+				Config: &pokeapi.Config{
+					Cache:    pokecache.NewCache(time.Hour),
+					SaveFile: saveFile,
+					Pokedex:  map[string]pokeapi.Pokemon{"pikachu": {Name: "pikachu"}},
+				},
+			}
+
+			signals := make(chan os.Signal, 1)
+			if c.sigterm {
+				signals <- syscall.SIGTERM
+			}
+			code := runRepl(env, c.reader, signals)
+			if code != exitOK {
+				t.Errorf("expected exit code %v, got %v (stderr %q)", exitOK, code, errOut.String())
+			}
+			if !strings.Contains(out.String(), c.expected) {
+				t.Errorf("expected output to contain %q, got %q", c.expected, out.String())
+			}
+			if c.notOut != "" && strings.Contains(out.String(), c.notOut) {
+				t.Errorf("expected output not to contain %q, got %q", c.notOut, out.String())
+			}
+
+			// shutdown ran: the Pokedex was saved and the cache closed
+			if _, err := os.Stat(saveFile); err != nil {
+				t.Errorf("expected the Pokedex to be saved on the way out: %v", err)
+			}
+			env.Config.Cache.Add("key", []byte("val"))
+			if _, found := env.Config.Cache.Get("key"); found {
+				t.Errorf("expected the cache to be closed on the way out")
+			}
+		})
+	}
+}
+
+func TestCompleteLine(t *testing.T) {
+	words := []string{"explore", "exit", "map", "mapb"}
+	complete := func(line string) []string {
+		fields := strings.Fields(line)
+		current := ""
+		if len(fields) > 0 && !strings.HasSuffix(line, " ") {
+			current = fields[len(fields)-1]
+		}
+		var matches []string
+		for _, w := range words {
+			if strings.HasPrefix(w, current) {
+				matches = append(matches, w)
+			}
+		}
+		return matches
+	}
+
+	cases := []struct {
+		line        string
+		pos         int
+		expected    string
+		expectedPos int
+	}{
+		// One match is finished off with a space
+		{line: "expl", pos: 4, expected: "explore ", expectedPos: 8},
+		// Several are extended as far as they agree
+		{line: "ma", pos: 2, expected: "map", expectedPos: 3},
+		// Nothing to add - line left alone
+		{line: "map", pos: 3, expected: "map", expectedPos: 3},
+		{line: "zz", pos: 2, expected: "zz", expectedPos: 2},
+		// Only the word before the cursor is completed
+		{line: "help expl pikachu", pos: 9, expected: "help explore  pikachu", expectedPos: 13},
+	}
+
+	for _, c := range cases {
+		t.Run(c.line, func(t *testing.T) {
+			line, pos, _ := completeLine(c.line, c.pos, complete)
+			if line != c.expected || pos != c.expectedPos {
+				t.Errorf("Expected %q at %v, got %q at %v", c.expected, c.expectedPos, line, pos)
+			}
+		})
+	}
+}
+
+// Run f with os.Stdout going to a pipe and return what it wrote
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("could not create pipe: %v", err)
+	}
+	original := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+
+	f()
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("could not read stdout: %v", err)
+	}
+	return string(out)
+}
+
+// Startup warnings must go to stderr, or they end up in front of the
+// results when running with -output json
+func TestSetupProfilesWarnings(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	config := &pokeapi.Config{Pokedex: make(map[string]pokeapi.Pokemon)}
+	var stderr bytes.Buffer
+	stdout := captureStdout(t, func() {
+		setupProfiles(config, settingsLayers{}, "nobody", &stderr)
+	})
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got: %q", stdout)
+	}
+	if !strings.Contains(stderr.String(), "no profile called nobody - using profile default") {
+		t.Errorf("expected a warning about the profile on stderr, got: %q", stderr.String())
+	}
+}
+
+func TestLoadPokedexCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.json")
+	if err := os.WriteFile(path, []byte(`{"version": 1, "pokemon": {`), 0o644); err != nil {
+		t.Fatalf("could not write save file: %v", err)
+	}
+	config := &pokeapi.Config{SaveFile: path}
+	var stderr bytes.Buffer
+	stdout := captureStdout(t, func() {
+		loadPokedex(config, &stderr)
+	})
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got: %q", stdout)
+	}
+	if !strings.Contains(stderr.String(), "Saving disabled for this session") {
+		t.Errorf("expected a warning on stderr, got: %q", stderr.String())
+	}
+	// The bad file mustn't be saved over
+	if config.SaveFile != "" {
+		t.Errorf("expected saving to be disabled, got save file: %v", config.SaveFile)
+	}
+}
+
+func TestNewCacheUnwritable(t *testing.T) {
+	// A file where the cache directory should be, so it can't be created
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pokedexcli"), nil, 0o644); err != nil {
+		t.Fatalf("could not create file: %v", err)
+	}
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	var stderr bytes.Buffer
+	var cache *pokecache.Cache
+	stdout := captureStdout(t, func() {
+		cache = newCache(&stderr)
+	})
+	defer cache.Close()
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got: %q", stdout)
+	}
+	if !strings.Contains(stderr.String(), "Disk cache disabled") {
+		t.Errorf("expected a warning on stderr, got: %q", stderr.String())
+	}
+}
+
+func TestCacheLimitsAndStats(t *testing.T) {
+	var log bytes.Buffer
+	config := &pokeapi.Config{Cache: pokecache.NewCache(time.Hour), Log: &log}
+	applySettings(config, settings.Settings{CacheMaxEntries: 2, Verbose: true})
+
+	for _, key := range []string{"one", "two", "three"} {
+		config.Cache.Add(key, []byte(key))
+	}
+	if stats := config.Cache.Stats(); stats.Entries != 2 || stats.EvictedByCount != 1 {
+		t.Errorf("expected the entry limit from settings to apply, got stats: %+v", stats)
+	}
+
+	if err := shutdown(config); err != nil {
+		t.Fatalf("shutdown returned error: %v", err)
+	}
+	expected := "Cache: 2 entries, 16 bytes in memory. Evicted 1 over the entry limit"
+	if !strings.Contains(log.String(), expected) {
+		t.Errorf("expected verbose shutdown to log %q, got: %q", expected, log.String())
+	}
+}
+
+func TestNewClientTimeout(t *testing.T) {
+	cases := []struct {
+		timeout  float64
+		expected time.Duration
+	}{
+		{timeout: 0, expected: pokeapi.DefaultTimeout},
+		{timeout: 0.5, expected: 500 * time.Millisecond},
+		{timeout: 10, expected: 10 * time.Second},
+	}
+	for _, c := range cases {
+		client := newClient(settings.Settings{Timeout: c.timeout}, nil)
+		if client.HTTP.Timeout != c.expected {
+			t.Errorf("timeout %v: expected client timeout %v, got: %v", c.timeout, c.expected, client.HTTP.Timeout)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Fraegdegjevar/pokedexcli/internal/command"
+)
+
+// Exit codes for non-interactive runs
+const (
+	exitOK        = 0
+	exitFailure   = 1   // a command failed
+	exitUsage     = 2   // bad flags, settings or script file
+	exitInterrupt = 130 // Ctrl-C, as the shell would report it
+)
+
+// Run REPL commands from r, one per line, without prompting - used for -c,
+// a command after the flags and -script. Blank lines and lines starting
+// with # are skipped. We stop at the first failing command (like sh -e) so
+// scripts don't carry on in a bad state. The session is closed (saving the
+// Pokedex) before returning the exit code.
+func runNonInteractive(env *command.Env, r io.Reader, source string) int {
+	// Ctrl-C or SIGTERM cancels the running command and stops the script
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	code := runCommands(ctx, env, r, source)
+
+	if err := shutdown(env.Config); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if code == exitOK {
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+// Run each line from r through the command registry and return the exit code.
+// Errors are reported to env.Stderr along with where they came from.
+func runCommands(ctx context.Context, env *command.Env, r io.Reader, source string) int {
+	supportedCommands := command.GetSupportedCommands()
+	scanner := bufio.NewScanner(r)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		err := command.ExecuteCommand(ctx, supportedCommands, cleanInput(line), env)
+		// exit ends the script early, successfully
+		if errors.Is(err, command.ErrExit) {
+			return exitOK
+		}
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(env.Stderr, "%s:%d: interrupted\n", source, lineNumber)
+			return exitInterrupt
+		}
+		if err != nil {
+			fmt.Fprintf(env.Stderr, "%s:%d: %v\n", source, lineNumber, err)
+			return exitFailure
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(env.Stderr, "error reading %s: %v\n", source, err)
+		return exitUsage
+	}
+	return exitOK
+}